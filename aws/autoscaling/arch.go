@@ -0,0 +1,94 @@
+package autoscaling
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/doitintl/spotzero/aws/ec2"
+)
+
+const (
+	// Graviton instance types architecture
+	archArm64 = "arm64"
+	// default launch template version
+	defaultVersion = "$Default"
+)
+
+// parseLaunchTemplate parses `id-or-name[:version]` launch template; version defaults to $Default
+func parseLaunchTemplate(value string) (*autoscaling.LaunchTemplateSpecification, error) {
+	value = strings.TrimSpace(value)
+	version := defaultVersion
+	if i := strings.LastIndex(value, ":"); i >= 0 {
+		value, version = value[:i], value[i+1:]
+	}
+	if value == "" || version == "" {
+		return nil, fmt.Errorf("invalid launch template %q: expected id-or-name[:version]", value)
+	}
+	spec := &autoscaling.LaunchTemplateSpecification{Version: aws.String(version)}
+	if strings.HasPrefix(value, "lt-") {
+		spec.LaunchTemplateId = aws.String(value)
+	} else {
+		spec.LaunchTemplateName = aws.String(value)
+	}
+	return spec, nil
+}
+
+// armLaunchTemplate returns the launch template for arm64 instance types or nil, if cross architecture is not configured.
+// A copy of the group launch template with the arm64 AMI is created, unless preview is requested.
+func (s *asgUpdaterService) armLaunchTemplate(ctx context.Context, template *autoscaling.LaunchTemplateSpecification, config Config, create bool) (*autoscaling.LaunchTemplateSpecification, error) {
+	if config.ArmLaunchTemplate != "" {
+		return parseLaunchTemplate(config.ArmLaunchTemplate)
+	}
+	if config.ArmImageID == "" {
+		return nil, nil
+	}
+	if create {
+		spec, err := s.ltsvc.CreateWithImage(ctx, template, config.ArmImageID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create arm64 launch template: %v", err)
+		}
+		return spec, nil
+	}
+	// launch template referenced by name and version number, for example, not created from the launch configuration yet
+	if version, err := strconv.ParseInt(aws.StringValue(template.Version), 10, 64); err == nil && template.LaunchTemplateId == nil {
+		name := ec2.ImageTemplateName(aws.StringValue(template.LaunchTemplateName), version, config.ArmImageID)
+		return &autoscaling.LaunchTemplateSpecification{LaunchTemplateName: aws.String(name), Version: aws.String(defaultVersion)}, nil
+	}
+	name, err := s.ltsvc.ImageLaunchTemplateName(ctx, template, config.ArmImageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get arm64 launch template: %v", err)
+	}
+	return &autoscaling.LaunchTemplateSpecification{LaunchTemplateName: aws.String(name), Version: aws.String(defaultVersion)}, nil
+}
+
+// mergeArchOverrides appends arm64 overrides (with the arm64 launch template) to the original architecture overrides.
+// arm64 instance types get up to half of maxAsgTypes, or more if there are less original architecture instance types.
+func mergeArchOverrides(overrides, armOverrides []*autoscaling.LaunchTemplateOverrides, armTemplate *autoscaling.LaunchTemplateSpecification) []*autoscaling.LaunchTemplateOverrides {
+	types := make(map[string]bool, len(overrides))
+	for _, o := range overrides {
+		types[aws.StringValue(o.InstanceType)] = true
+	}
+	var arm []*autoscaling.LaunchTemplateOverrides
+	for _, o := range armOverrides {
+		// the original instance type is already arm64
+		if !types[aws.StringValue(o.InstanceType)] {
+			o.LaunchTemplateSpecification = armTemplate
+			arm = append(arm, o)
+		}
+	}
+	armCount := len(arm)
+	if armCount > maxAsgTypes/2 {
+		armCount = maxAsgTypes / 2
+	}
+	if len(overrides) > maxAsgTypes-armCount {
+		overrides = overrides[:maxAsgTypes-armCount]
+	}
+	if len(arm) > maxAsgTypes-len(overrides) {
+		arm = arm[:maxAsgTypes-len(overrides)]
+	}
+	return append(overrides, arm...)
+}
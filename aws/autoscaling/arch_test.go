@@ -0,0 +1,120 @@
+package autoscaling
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	ec2mocks "github.com/doitintl/spotzero/mocks/ec2"
+)
+
+func Test_parseLaunchTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    *autoscaling.LaunchTemplateSpecification
+		wantErr bool
+	}{
+		{name: "id", value: "lt-0123", want: &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-0123"), Version: aws.String("$Default")}},
+		{name: "name and version", value: "web-arm64:3", want: &autoscaling.LaunchTemplateSpecification{LaunchTemplateName: aws.String("web-arm64"), Version: aws.String("3")}},
+		{name: "fail: empty version", value: "web-arm64:", wantErr: true},
+		{name: "fail: empty name", value: ":3", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLaunchTemplate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseLaunchTemplate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLaunchTemplate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func testTypes(prefix string, n int) []string {
+	var typeWeights []string
+	for i := 1; i <= n; i++ {
+		typeWeights = append(typeWeights, prefix+string(rune('a'+i-1))+".large", "2")
+	}
+	return typeWeights
+}
+
+func Test_mergeArchOverrides(t *testing.T) {
+	armTemplate := &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-arm"), Version: aws.String("1")}
+	tests := []struct {
+		name      string
+		overrides []*autoscaling.LaunchTemplateOverrides
+		arm       []*autoscaling.LaunchTemplateOverrides
+		wantTypes int
+		wantArm   int
+	}{
+		{name: "few instance types", overrides: testOverrides(testTypes("m5", 3)...), arm: testOverrides(testTypes("m6g", 2)...), wantTypes: 3, wantArm: 2},
+		{name: "arm64 gets half", overrides: testOverrides(testTypes("m5", 20)...), arm: testOverrides(testTypes("m6g", 15)...), wantTypes: 10, wantArm: 10},
+		{name: "arm64 fills the rest", overrides: testOverrides(testTypes("m5", 4)...), arm: testOverrides(testTypes("m6g", 20)...), wantTypes: 4, wantArm: 16},
+		{name: "original arm64 instance types", overrides: testOverrides("m6g.large", "2"), arm: testOverrides("m6g.large", "2", "m6gd.large", "2"), wantTypes: 1, wantArm: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeArchOverrides(tt.overrides, tt.arm, armTemplate)
+			var types, arm int
+			for _, o := range got {
+				if o.LaunchTemplateSpecification == armTemplate {
+					arm++
+				} else {
+					types++
+				}
+			}
+			if types != tt.wantTypes || arm != tt.wantArm {
+				t.Errorf("mergeArchOverrides() got %d original and %d arm64 instance types, want %d and %d", types, arm, tt.wantTypes, tt.wantArm)
+			}
+		})
+	}
+}
+
+func Test_asgUpdaterService_armLaunchTemplate(t *testing.T) {
+	template := &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-1"), Version: aws.String("3")}
+	created := &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-2"), Version: aws.String("1")}
+	// launch template, that launch configuration migration creates
+	migrated := &autoscaling.LaunchTemplateSpecification{LaunchTemplateName: aws.String("spotzero-web-lc"), Version: aws.String("1")}
+	tests := []struct {
+		name     string
+		template *autoscaling.LaunchTemplateSpecification
+		config   Config
+		create   bool
+		want     *autoscaling.LaunchTemplateSpecification
+	}{
+		{name: "not configured", config: Config{}},
+		{name: "arm64 launch template", config: Config{ArmLaunchTemplate: "lt-3:2"}, create: true,
+			want: &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-3"), Version: aws.String("2")}},
+		{name: "create arm64 launch template", config: Config{ArmImageID: "ami-arm"}, create: true, want: created},
+		{name: "preview arm64 launch template", config: Config{ArmImageID: "ami-arm"},
+			want: &autoscaling.LaunchTemplateSpecification{LaunchTemplateName: aws.String("spotzero-web-v3-ami-arm"), Version: aws.String("$Default")}},
+		{name: "preview arm64 launch template for launch configuration", template: migrated, config: Config{ArmImageID: "ami-arm"},
+			want: &autoscaling.LaunchTemplateSpecification{LaunchTemplateName: aws.String("spotzero-spotzero-web-lc-v1-ami-arm"), Version: aws.String("$Default")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mockLtSvc := new(ec2mocks.LaunchTemplateCreator)
+			mockLtSvc.On("CreateWithImage", ctx, template, "ami-arm").Return(created, nil)
+			mockLtSvc.On("ImageLaunchTemplateName", ctx, template, "ami-arm").Return("spotzero-web-v3-ami-arm", nil)
+			s := &asgUpdaterService{ltsvc: mockLtSvc}
+			source := template
+			if tt.template != nil {
+				source = tt.template
+			}
+			got, err := s.armLaunchTemplate(ctx, source, tt.config, tt.create)
+			if err != nil {
+				t.Fatalf("armLaunchTemplate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("armLaunchTemplate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,11 +5,11 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws/request"
 
-	"github.com/doitintl/spotzero/internal/math"
-
+	"github.com/doitintl/spotzero/aws/ec2"
 	"github.com/doitintl/spotzero/aws/sts"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -18,114 +18,145 @@ import (
 
 const (
 	maxRecordsReturnedByAPI = 100
-	maxAsgNamesPerDescribe  = 50
 )
 
 // define interface for used methods only (simplify testing)
 type awsAutoScaling interface {
-	DescribeTagsPagesWithContext(aws.Context, *autoscaling.DescribeTagsInput, func(*autoscaling.DescribeTagsOutput, bool) bool, ...request.Option) error
 	DescribeAutoScalingGroupsPagesWithContext(aws.Context, *autoscaling.DescribeAutoScalingGroupsInput, func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool, ...request.Option) error
 }
 
 type asgService struct {
-	svc awsAutoScaling
+	svc    awsAutoScaling
+	ec2svc ec2.InstanceDescriber
 }
 
 // Lister interface contains methods to list EC2 Auto Scaling groups
 type Lister interface {
-	List(ctx context.Context, tags map[string]string) ([]*autoscaling.Group, error)
+	List(ctx context.Context, filter GroupFilter) ([]*autoscaling.Group, []*SkippedGroup, error)
+	ListUpdated(ctx context.Context, filter GroupFilter) ([]*autoscaling.Group, error)
 }
 
 // NewLister creates a new Lister
 func NewLister(role sts.AssumeRoleInRegion) Lister {
-	return &asgService{svc: autoscaling.New(sts.MustAwsSession(role.Arn, role.ExternalID, role.Region))}
+	return &asgService{
+		svc:    autoscaling.New(sts.MustAwsSession(role.Arn, role.ExternalID, role.Region)),
+		ec2svc: ec2.NewInstanceDescriber(role),
+	}
 }
 
-// List list EC2 Auto Scaling groups filtered by AWS tags.
-// Tags are specified in a map: `key` for tag name and `value` for tag value.
-// It returns a list of EC2 Auto Scaling groups.
-func (s *asgService) List(ctx context.Context, tags map[string]string) ([]*autoscaling.Group, error) {
-	var asgs []*autoscaling.Group
-	log.Printf("listing autoscaling groups matching tags: %v", tags)
-	// asgNamesSet idiomatic Go way to implement set of strings
-	asgNamesSet := make(map[string]bool)
-	{
-		var asFilters []*autoscaling.Filter
-		for k, v := range tags {
-			// Not an exact match, but likely the best we can do
-			asFilters = append(asFilters,
-				&autoscaling.Filter{
-					Name:   aws.String("key"),
-					Values: []*string{aws.String(k)},
-				},
-				&autoscaling.Filter{
-					Name:   aws.String("value"),
-					Values: []*string{aws.String(v)},
-				},
-			)
-		}
-		req := &autoscaling.DescribeTagsInput{
-			Filters:    asFilters,
-			MaxRecords: aws.Int64(maxRecordsReturnedByAPI),
-		}
+// List list EC2 Auto Scaling groups filtered by AWS tags Selector and group names.
+// It returns a list of EC2 Auto Scaling groups eligible for update and a list of skipped groups with skip reason.
+func (s *asgService) List(ctx context.Context, filter GroupFilter) ([]*autoscaling.Group, []*SkippedGroup, error) {
+	return s.list(ctx, filter, false)
+}
 
-		err := s.svc.DescribeTagsPagesWithContext(ctx, req, func(p *autoscaling.DescribeTagsOutput, lastPage bool) bool {
-			for _, t := range p.Tags {
-				switch *t.ResourceType {
-				case "auto-scaling-group":
-					// add asg name to set of strings
-					asgNamesSet[*t.ResourceId] = true
-				default:
-					log.Printf("unexpected resource type: %v", *t.ResourceType)
-				}
-			}
-			return true
-		})
-		if err != nil {
-			return nil, fmt.Errorf("error listing autoscaling cluster tags: %v", err)
-		}
-	}
+// ListUpdated list EC2 Auto Scaling groups, filtered by AWS tags Selector and group names, that were already
+// updated by spotzero.
+// It returns a list of EC2 Auto Scaling groups with "spotzero:updated=true" tag.
+func (s *asgService) ListUpdated(ctx context.Context, filter GroupFilter) ([]*autoscaling.Group, error) {
+	asgs, _, err := s.list(ctx, filter, true)
+	return asgs, err
+}
 
-	if len(asgNamesSet) != 0 {
-		// copy names to array
-		i := 0
-		asgNames := make([]*string, len(asgNamesSet))
-		for k := range asgNamesSet {
-			asgNames[i] = aws.String(k)
-			i++
-		}
-		for i := 0; i < len(asgNamesSet); i += maxAsgNamesPerDescribe {
-			batch := asgNames[i:math.MinInt(i+maxAsgNamesPerDescribe, len(asgNames))]
+func (s *asgService) list(ctx context.Context, filter GroupFilter, updated bool) ([]*autoscaling.Group, []*SkippedGroup, error) {
+	var asgs []*autoscaling.Group
+	var skipped []*SkippedGroup
+	log.Printf("listing autoscaling groups matching: %v", filter)
+	// asgNamesSet idiomatic Go way to implement set of strings; OR groups can select the same ASG
+	asgNamesSet := make(map[string]bool)
+	for _, filters := range filter.Tags.groupFilters() {
+		for _, names := range filter.Names.nameBatches() {
 			req := &autoscaling.DescribeAutoScalingGroupsInput{
-				AutoScalingGroupNames: batch,
-				MaxRecords:            aws.Int64(maxAsgNamesPerDescribe),
+				AutoScalingGroupNames: names,
+				Filters:               filters,
+				MaxRecords:            aws.Int64(maxRecordsReturnedByAPI),
 			}
-			err := s.svc.DescribeAutoScalingGroupsPagesWithContext(ctx, req, func(p *autoscaling.DescribeAutoScalingGroupsOutput, lastPage bool) bool {
-				for _, asg := range p.AutoScalingGroups {
-					// We used an inexact filter above, so check exact match now
-					if !matchesAsgTags(tags, asg.Tags) {
-						continue
-					}
-					// Check if ASG is already updated, i.e. skip "spotzero:managed=true" tag
-					if matchesAsgTags(map[string]string{spotzeroUpdatedTag: "true"}, asg.Tags) {
-						continue
+			err := s.describeGroups(ctx, req, func(asg *autoscaling.Group) {
+				if asgNamesSet[*asg.AutoScalingGroupName] {
+					return
+				}
+				asgNamesSet[*asg.AutoScalingGroupName] = true
+				// Server side filters do not cover wildcards, negations and name patterns, so check exact match now
+				if !filter.selects(asg) {
+					return
+				}
+				// Check if ASG is already updated, i.e. has "spotzero:updated=true" tag; re-optimize it when due
+				if isUpdated(asg) != updated {
+					if updated {
+						return
 					}
-					// Check for "Delete in progress" (the only use of .Status)
-					if asg.Status != nil {
-						log.Printf("skipping ASG %v (which matches tags): %v", *asg.AutoScalingGroupARN, *asg.Status)
-						continue
+					if message := filter.reoptimizeSkip(asg, time.Now()); message != "" {
+						skipped = append(skipped, skipGroup(asg, SkipAlreadyUpdated, message))
+						return
 					}
-					asgs = append(asgs, asg)
 				}
-				return true
+				if reason, message := s.skipReason(ctx, filter, asg, updated); reason != "" {
+					skipped = append(skipped, skipGroup(asg, reason, message))
+					return
+				}
+				asgs = append(asgs, asg)
 			})
 			if err != nil {
-				return nil, fmt.Errorf("error listing autoscaling groups: %v", err)
+				return nil, nil, err
 			}
 		}
 	}
 
-	return asgs, nil
+	return asgs, skipped, nil
+}
+
+func skipGroup(group *autoscaling.Group, reason SkipReason, message string) *SkippedGroup {
+	log.Printf("skipping ASG %v (which matches filter): %v, %v", *group.AutoScalingGroupARN, reason, message)
+	return &SkippedGroup{Group: group, Reason: reason, Message: message}
+}
+
+// skipReason checks if the ASG, selected by the filter, is not eligible for update (or rollback, if updated)
+func (s *asgService) skipReason(ctx context.Context, filter GroupFilter, group *autoscaling.Group, updated bool) (SkipReason, string) {
+	if message := filter.exclusion(group); message != "" {
+		return SkipExcludedByPolicy, message
+	}
+	// Check for "Delete in progress" (the only use of .Status)
+	if group.Status != nil {
+		return SkipDeleting, *group.Status
+	}
+	if updated {
+		return "", ""
+	}
+	if group.LaunchConfigurationName != nil {
+		if filter.migrates(group) {
+			return "", ""
+		}
+		return SkipLaunchConfiguration, "autoscaling group with launch configuration is not supported"
+	}
+	// check if LaunchTemplate is requesting Spot instances in configuration
+	lts, err := launchTemplateSpec(group)
+	if err != nil {
+		return "", ""
+	}
+	instance, err := s.ec2svc.GetInstanceDetails(ctx, lts)
+	if err != nil {
+		// let update report the error
+		log.Printf("failed to get instance details for ASG %v: %v", *group.AutoScalingGroupARN, err)
+		return "", ""
+	}
+	if instance.MarketType == ec2.SpotMarketType {
+		return SkipSpotLaunchTemplate, "launch template is already requesting for spot instances"
+	}
+	return "", ""
+}
+
+// describeGroups pages over DescribeAutoScalingGroups results and calls fn for every group
+func (s *asgService) describeGroups(ctx context.Context, req *autoscaling.DescribeAutoScalingGroupsInput, fn func(*autoscaling.Group)) error {
+	err := s.svc.DescribeAutoScalingGroupsPagesWithContext(ctx, req, func(p *autoscaling.DescribeAutoScalingGroupsOutput, lastPage bool) bool {
+		for _, asg := range p.AutoScalingGroups {
+			fn(asg)
+		}
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("error listing autoscaling groups: %v", err)
+	}
+	return nil
 }
 
 // matchesAsgTags is used to filter asg groups by tags
@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/doitintl/spotzero/aws/ec2"
 	"github.com/doitintl/spotzero/mocks"
+	ec2mocks "github.com/doitintl/spotzero/mocks/ec2"
 
 	"github.com/aws/aws-sdk-go/aws"
 
@@ -37,67 +41,227 @@ func testNamedDescribeAutoScalingGroupsOutput(groupName string, desiredCap int64
 	}
 }
 
+func testDescribeInput(names []string, filters ...*autoscaling.Filter) *autoscaling.DescribeAutoScalingGroupsInput {
+	input := &autoscaling.DescribeAutoScalingGroupsInput{
+		Filters:    filters,
+		MaxRecords: aws.Int64(maxRecordsReturnedByAPI),
+	}
+	if names != nil {
+		input.AutoScalingGroupNames = aws.StringSlice(names)
+	}
+	return input
+}
+
+//nolint:funlen
 func Test_asgService_ListGroups(t *testing.T) {
 	type args struct {
-		ctx  context.Context
-		tags map[string]string
+		ctx      context.Context
+		selector string
+		names    []string
+		exclude  []string
+		config   string
+		reopt    time.Duration
 	}
 	tests := []struct {
 		name    string
 		args    args
-		want    []*autoscaling.Group
+		inputs  []*autoscaling.DescribeAutoScalingGroupsInput
+		tags    []*autoscaling.TagDescription
+		group   func(group *autoscaling.Group)
+		details *ec2.InstanceDetails
+		want    []string
+		skipped []SkipReason
 		wantErr bool
 	}{
 		{
 			name: "list asg groups",
-			args: args{context.TODO(), map[string]string{"test-tag": "test-value"}},
+			args: args{ctx: context.TODO(), selector: "test-tag=test-value"},
+			inputs: []*autoscaling.DescribeAutoScalingGroupsInput{
+				testDescribeInput(nil, &autoscaling.Filter{Name: aws.String("tag:test-tag"), Values: aws.StringSlice([]string{"test-value"})}),
+			},
+		},
+		{
+			name:   "list untagged asg groups",
+			args:   args{ctx: context.TODO()},
+			inputs: []*autoscaling.DescribeAutoScalingGroupsInput{testDescribeInput(nil)},
+			want:   []string{"auto-asg"},
+		},
+		{
+			name: "list asg groups matching multiple or groups",
+			args: args{ctx: context.TODO(), selector: "env=dev || env in (qa,stage),team"},
+			inputs: []*autoscaling.DescribeAutoScalingGroupsInput{
+				testDescribeInput(nil, &autoscaling.Filter{Name: aws.String("tag:env"), Values: aws.StringSlice([]string{"dev"})}),
+				testDescribeInput(nil,
+					&autoscaling.Filter{Name: aws.String("tag:env"), Values: aws.StringSlice([]string{"qa", "stage"})},
+					&autoscaling.Filter{Name: aws.String("tag-key"), Values: aws.StringSlice([]string{"team"})},
+				),
+			},
+			tags: testTags("env", "dev", "team", "web"),
+			want: []string{"auto-asg"},
+		},
+		{
+			name:   "list asg groups matching wildcard",
+			args:   args{ctx: context.TODO(), selector: "env=dev*"},
+			inputs: []*autoscaling.DescribeAutoScalingGroupsInput{testDescribeInput(nil)},
+			tags:   testTags("env", "qa"),
+		},
+		{
+			name:    "skip updated asg groups",
+			args:    args{ctx: context.TODO()},
+			inputs:  []*autoscaling.DescribeAutoScalingGroupsInput{testDescribeInput(nil)},
+			tags:    testTags(spotzeroUpdatedTag, "true"),
+			skipped: []SkipReason{SkipAlreadyUpdated},
+		},
+		{
+			name:   "list updated asg groups due for re-optimization",
+			args:   args{ctx: context.TODO(), reopt: time.Hour},
+			inputs: []*autoscaling.DescribeAutoScalingGroupsInput{testDescribeInput(nil)},
+			tags:   testTags(spotzeroUpdatedTag, "true", spotzeroUpdatedTimeTag, "2022-05-10T14:30:15Z"),
+			want:   []string{"auto-asg"},
+		},
+		{
+			name:    "skip recently updated asg groups",
+			args:    args{ctx: context.TODO(), reopt: time.Hour},
+			inputs:  []*autoscaling.DescribeAutoScalingGroupsInput{testDescribeInput(nil)},
+			tags:    testTags(spotzeroUpdatedTag, "true", spotzeroUpdatedTimeTag, time.Now().UTC().Format(time.RFC3339)),
+			skipped: []SkipReason{SkipAlreadyUpdated},
+		},
+		{
+			name:    "skip deleting asg groups",
+			args:    args{ctx: context.TODO()},
+			inputs:  []*autoscaling.DescribeAutoScalingGroupsInput{testDescribeInput(nil)},
+			group:   func(group *autoscaling.Group) { group.Status = aws.String("Delete in progress") },
+			skipped: []SkipReason{SkipDeleting},
+		},
+		{
+			name:    "skip asg groups with launch configuration",
+			args:    args{ctx: context.TODO()},
+			inputs:  []*autoscaling.DescribeAutoScalingGroupsInput{testDescribeInput(nil)},
+			group:   func(group *autoscaling.Group) { group.LaunchConfigurationName = aws.String("test-lc") },
+			skipped: []SkipReason{SkipLaunchConfiguration},
+		},
+		{
+			name:   "skip asg groups with spot launch template",
+			args:   args{ctx: context.TODO()},
+			inputs: []*autoscaling.DescribeAutoScalingGroupsInput{testDescribeInput(nil)},
+			group: func(group *autoscaling.Group) {
+				group.LaunchTemplate = &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-1"), Version: aws.String("1")}
+			},
+			details: &ec2.InstanceDetails{TypeName: "m5.large", MarketType: ec2.SpotMarketType},
+			skipped: []SkipReason{SkipSpotLaunchTemplate},
+		},
+		{
+			name:   "list asg groups with on-demand launch template",
+			args:   args{ctx: context.TODO()},
+			inputs: []*autoscaling.DescribeAutoScalingGroupsInput{testDescribeInput(nil)},
+			group: func(group *autoscaling.Group) {
+				group.LaunchTemplate = &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-1"), Version: aws.String("1")}
+			},
+			details: &ec2.InstanceDetails{TypeName: "m5.large", MarketType: ec2.OnDemandMarketType},
+			want:    []string{"auto-asg"},
+		},
+		{
+			name: "list asg groups by names and ARNs",
+			args: args{ctx: context.TODO(), names: []string{"auto-asg", "arn:aws:autoscaling:us-east-1:123456789012:autoScalingGroup:uuid:autoScalingGroupName/other-asg"}},
+			inputs: []*autoscaling.DescribeAutoScalingGroupsInput{
+				testDescribeInput([]string{"auto-asg", "other-asg"}),
+			},
+			want: []string{"auto-asg"},
+		},
+		{
+			name:   "list asg groups by glob",
+			args:   args{ctx: context.TODO(), names: []string{"auto-*"}},
+			inputs: []*autoscaling.DescribeAutoScalingGroupsInput{testDescribeInput(nil)},
+			want:   []string{"auto-asg"},
+		},
+		{
+			name:    "exclude asg groups by regular expression",
+			args:    args{ctx: context.TODO(), exclude: []string{"/^auto-/"}},
+			inputs:  []*autoscaling.DescribeAutoScalingGroupsInput{testDescribeInput(nil)},
+			skipped: []SkipReason{SkipExcludedByPolicy},
+		},
+		{
+			name:    "exclude asg groups by config rule",
+			args:    args{ctx: context.TODO(), config: "rules:\n  - match:\n      names: [auto-asg]\n    exclude: true\n"},
+			inputs:  []*autoscaling.DescribeAutoScalingGroupsInput{testDescribeInput(nil)},
+			skipped: []SkipReason{SkipExcludedByPolicy},
+		},
+		{
+			name:   "list asg groups with launch configuration migrated by config rule",
+			args:   args{ctx: context.TODO(), config: "rules:\n  - config:\n      migrate-launch-configurations: true\n"},
+			inputs: []*autoscaling.DescribeAutoScalingGroupsInput{testDescribeInput(nil)},
+			group:  func(group *autoscaling.Group) { group.LaunchConfigurationName = aws.String("test-lc") },
+			want:   []string{"auto-asg"},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockAsgSvc := new(mocks.AwsAutoScaling)
+			mockEc2Svc := new(ec2mocks.InstanceDescriber)
 			s := &asgService{
-				svc: mockAsgSvc,
+				svc:    mockAsgSvc,
+				ec2svc: mockEc2Svc,
+			}
+			if tt.details != nil {
+				mockEc2Svc.On("GetInstanceDetails", tt.args.ctx, mock.AnythingOfType("*autoscaling.LaunchTemplateSpecification")).
+					Return(tt.details, nil).Once()
+			}
+			for _, input := range tt.inputs {
+				mockAsgSvc.On("DescribeAutoScalingGroupsPagesWithContext",
+					tt.args.ctx,
+					input,
+					mock.AnythingOfType("func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool"),
+				).Run(func(args mock.Arguments) {
+					fn := args.Get(2).(func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool)
+					output := testNamedDescribeAutoScalingGroupsOutput("auto-asg", 1, "test-instance-id")
+					output.AutoScalingGroups[0].Tags = tt.tags
+					output.AutoScalingGroups[0].AutoScalingGroupARN = aws.String("test-asg-arn")
+					if tt.group != nil {
+						tt.group(output.AutoScalingGroups[0])
+					}
+					fn(output, true)
+				}).Return(nil).Once()
 			}
-			mockAsgSvc.On("DescribeTagsPagesWithContext",
-				tt.args.ctx,
-				&autoscaling.DescribeTagsInput{
-					Filters: []*autoscaling.Filter{
-						{Name: aws.String("key"), Values: aws.StringSlice([]string{"test-tag"})},
-						{Name: aws.String("value"), Values: aws.StringSlice([]string{"test-value"})},
-					},
-					MaxRecords: aws.Int64(maxRecordsReturnedByAPI),
-				},
-				mock.AnythingOfType("func(*autoscaling.DescribeTagsOutput, bool) bool"),
-			).Run(func(args mock.Arguments) {
-				fn := args.Get(2).(func(*autoscaling.DescribeTagsOutput, bool) bool)
-				fn(&autoscaling.DescribeTagsOutput{
-					Tags: []*autoscaling.TagDescription{
-						{ResourceId: aws.String("auto-asg"), ResourceType: aws.String("auto-scaling-group")},
-					}}, false)
-			}).Return(nil).Once()
-			mockAsgSvc.On("DescribeAutoScalingGroupsPagesWithContext",
-				tt.args.ctx,
-				&autoscaling.DescribeAutoScalingGroupsInput{
-					AutoScalingGroupNames: aws.StringSlice([]string{"auto-asg"}),
-					MaxRecords:            aws.Int64(maxAsgNamesPerDescribe),
-				},
-				mock.AnythingOfType("func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool"),
-			).Run(func(args mock.Arguments) {
-				fn := args.Get(2).(func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool)
-				fn(testNamedDescribeAutoScalingGroupsOutput("auto-asg", 1, "test-instance-id"), false)
-			}).Return(nil)
 
-			got, err := s.List(tt.args.ctx, tt.args.tags)
+			var filter GroupFilter
+			var err error
+			filter.Tags, err = ParseSelector(tt.args.selector)
+			if err != nil {
+				t.Errorf("ParseSelector() error = %v", err)
+				return
+			}
+			filter.Names, _ = ParseNameMatcher(tt.args.names)
+			filter.ExcludeNames, _ = ParseNameMatcher(tt.args.exclude)
+			filter.ReoptimizeAfter = tt.args.reopt
+			if tt.args.config != "" {
+				filter.Policy, err = parsePolicy(strings.NewReader(tt.args.config))
+				if err != nil {
+					t.Errorf("parsePolicy() error = %v", err)
+					return
+				}
+			}
+			got, skipped, err := s.List(tt.args.ctx, filter)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("List() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("List() got = %v, want %v", got, tt.want)
+			var names []string
+			for _, g := range got {
+				names = append(names, *g.AutoScalingGroupName)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("List() got = %v, want %v", names, tt.want)
+			}
+			var reasons []SkipReason
+			for _, g := range skipped {
+				reasons = append(reasons, g.Reason)
+			}
+			if !reflect.DeepEqual(reasons, tt.skipped) {
+				t.Errorf("List() skipped = %v, want %v", reasons, tt.skipped)
 			}
 			// assert mock
 			mockAsgSvc.AssertExpectations(t)
+			mockEc2Svc.AssertExpectations(t)
 		})
 	}
 }
@@ -0,0 +1,129 @@
+package autoscaling
+
+import (
+	"context"
+	"log"
+	"math"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/doitintl/spotzero/aws/ec2"
+)
+
+const (
+	// desired capacity types: instances (units), VCPUs or memory (MiB)
+	capacityTypeUnits  = "units"
+	capacityTypeVCPU   = "vcpu"
+	capacityTypeMemory = "memory-mib"
+	mibInGiB           = 1024
+	// scaling policy adjustment types in number of instances (capacity units)
+	adjustmentChangeInCapacity = "ChangeInCapacity"
+	adjustmentExactCapacity    = "ExactCapacity"
+)
+
+// desiredCapacityType returns the desired capacity type for the update: the weighting unit with instance requirements,
+// or units, if the group uses another desired capacity type; nil keeps the group setting
+func desiredCapacityType(group *autoscaling.Group, config Config) *string {
+	if config.InstanceRequirements {
+		switch config.Weighting {
+		case WeightingVCPU:
+			return aws.String(capacityTypeVCPU)
+		case WeightingMemory:
+			return aws.String(capacityTypeMemory)
+		default:
+			return aws.String(capacityTypeUnits)
+		}
+	}
+	if t := aws.StringValue(group.DesiredCapacityType); t != "" && t != capacityTypeUnits {
+		return aws.String(capacityTypeUnits)
+	}
+	return nil
+}
+
+// capacityUnits returns the number of capacity units provided by a single instance of the instance type
+func capacityUnits(capacityType *string, overrides []*autoscaling.LaunchTemplateOverrides, instanceType string) float64 {
+	switch aws.StringValue(capacityType) {
+	case capacityTypeVCPU, capacityTypeMemory:
+		it, err := ec2.DescribeInstanceType(instanceType)
+		if err != nil {
+			log.Printf("failed to get capacity units for %v: %v", instanceType, err)
+			return 1
+		}
+		if aws.StringValue(capacityType) == capacityTypeVCPU {
+			return float64(it.Weight)
+		}
+		return it.Memory * mibInGiB
+	}
+	for _, o := range overrides {
+		if aws.StringValue(o.InstanceType) != instanceType || o.WeightedCapacity == nil {
+			continue
+		}
+		if weight, err := strconv.ParseFloat(*o.WeightedCapacity, 64); err == nil && weight > 0 {
+			return weight
+		}
+	}
+	return 1
+}
+
+// capacityScale returns the ratio between the proposed and the current capacity units of the original instance type
+func capacityScale(group *autoscaling.Group, input *autoscaling.UpdateAutoScalingGroupInput, instanceType string) float64 {
+	current := capacityUnits(group.DesiredCapacityType, currentOverrides(group), instanceType)
+	capacityType := input.DesiredCapacityType
+	if capacityType == nil {
+		capacityType = group.DesiredCapacityType
+	}
+	var overrides []*autoscaling.LaunchTemplateOverrides
+	if input.MixedInstancesPolicy != nil && input.MixedInstancesPolicy.LaunchTemplate != nil {
+		overrides = input.MixedInstancesPolicy.LaunchTemplate.Overrides
+	}
+	return capacityUnits(capacityType, overrides, instanceType) / current
+}
+
+// rescaleCapacity converts the group min size, max size and desired capacity into the new capacity units
+func rescaleCapacity(group *autoscaling.Group, input *autoscaling.UpdateAutoScalingGroupInput, scale float64) {
+	if scale == 1 || scale <= 0 {
+		return
+	}
+	rescale := func(v *int64) *int64 {
+		if v == nil {
+			return nil
+		}
+		return aws.Int64(int64(math.Ceil(float64(*v) * scale)))
+	}
+	input.MinSize = rescale(group.MinSize)
+	input.MaxSize = rescale(group.MaxSize)
+	input.DesiredCapacity = rescale(group.DesiredCapacity)
+	log.Printf("rescaling capacity of the autoscaling group %v (x%g): min size %d -> %d, max size %d -> %d, desired capacity %d -> %d",
+		aws.StringValue(group.AutoScalingGroupName), scale,
+		aws.Int64Value(group.MinSize), aws.Int64Value(input.MinSize),
+		aws.Int64Value(group.MaxSize), aws.Int64Value(input.MaxSize),
+		aws.Int64Value(group.DesiredCapacity), aws.Int64Value(input.DesiredCapacity))
+}
+
+// warnScalingActions logs scaling policies and scheduled actions, that set capacity in the old capacity units
+func (s *asgUpdaterService) warnScalingActions(ctx context.Context, group *autoscaling.Group, scale float64) {
+	name := aws.StringValue(group.AutoScalingGroupName)
+	policies, err := s.asgsvc.DescribePoliciesWithContext(ctx, &autoscaling.DescribePoliciesInput{AutoScalingGroupName: group.AutoScalingGroupName})
+	if err != nil {
+		log.Printf("failed to describe scaling policies of the autoscaling group %v: %v", name, err)
+	} else {
+		for _, p := range policies.ScalingPolicies {
+			if t := aws.StringValue(p.AdjustmentType); t == adjustmentChangeInCapacity || t == adjustmentExactCapacity {
+				log.Printf("warning: scaling policy %v of the autoscaling group %v uses %v adjustment; multiply its adjustments by %g",
+					aws.StringValue(p.PolicyName), name, t, scale)
+			}
+		}
+	}
+	actions, err := s.asgsvc.DescribeScheduledActionsWithContext(ctx, &autoscaling.DescribeScheduledActionsInput{AutoScalingGroupName: group.AutoScalingGroupName})
+	if err != nil {
+		log.Printf("failed to describe scheduled actions of the autoscaling group %v: %v", name, err)
+		return
+	}
+	for _, a := range actions.ScheduledUpdateGroupActions {
+		if a.MinSize != nil || a.MaxSize != nil || a.DesiredCapacity != nil {
+			log.Printf("warning: scheduled action %v of the autoscaling group %v sets capacity; multiply its min size, max size and desired capacity by %g",
+				aws.StringValue(a.ScheduledActionName), name, scale)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package autoscaling
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+func Test_capacityScale(t *testing.T) {
+	mixedGroup := func(capacityType *string, overrides []*autoscaling.LaunchTemplateOverrides) *autoscaling.Group {
+		return &autoscaling.Group{
+			DesiredCapacityType: capacityType,
+			MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{
+				LaunchTemplate: &autoscaling.LaunchTemplate{Overrides: overrides},
+			},
+		}
+	}
+	mixedInput := func(capacityType *string, overrides []*autoscaling.LaunchTemplateOverrides) *autoscaling.UpdateAutoScalingGroupInput {
+		return &autoscaling.UpdateAutoScalingGroupInput{
+			DesiredCapacityType: capacityType,
+			MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{
+				LaunchTemplate: &autoscaling.LaunchTemplate{Overrides: overrides},
+			},
+		}
+	}
+	tests := []struct {
+		name         string
+		group        *autoscaling.Group
+		input        *autoscaling.UpdateAutoScalingGroupInput
+		instanceType string
+		want         float64
+	}{
+		{
+			name:  "instances to vcpu weights",
+			group: &autoscaling.Group{LaunchTemplate: &autoscaling.LaunchTemplateSpecification{}},
+			input: mixedInput(nil, testOverrides("m5.xlarge", "4", "m5.2xlarge", "8")),
+			want:  4,
+		},
+		{
+			name:  "same weights",
+			group: mixedGroup(nil, testOverrides("m5.xlarge", "4")),
+			input: mixedInput(nil, testOverrides("m5.xlarge", "4", "m5.2xlarge", "8")),
+			want:  1,
+		},
+		{
+			name:  "vcpu to normalized weights",
+			group: mixedGroup(nil, testOverrides("m5.xlarge", "4")),
+			input: mixedInput(nil, testOverrides("m5.xlarge", "1", "m5.2xlarge", "2")),
+			want:  0.25,
+		},
+		{
+			name:  "instances to vcpu desired capacity type",
+			group: mixedGroup(nil, nil),
+			input: mixedInput(aws.String(capacityTypeVCPU), []*autoscaling.LaunchTemplateOverrides{{InstanceRequirements: &autoscaling.InstanceRequirements{}}}),
+			want:  4,
+		},
+		{
+			name:  "memory desired capacity type to instances",
+			group: mixedGroup(aws.String(capacityTypeMemory), nil),
+			input: mixedInput(aws.String(capacityTypeUnits), testOverrides("m5.xlarge", "1")),
+			want:  1.0 / 16384,
+		},
+		{
+			name:         "unknown instance type",
+			group:        mixedGroup(nil, nil),
+			input:        mixedInput(aws.String(capacityTypeVCPU), nil),
+			instanceType: "x0.tiny",
+			want:         1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instanceType := tt.instanceType
+			if instanceType == "" {
+				instanceType = "m5.xlarge"
+			}
+			if got := capacityScale(tt.group, tt.input, instanceType); got != tt.want {
+				t.Errorf("capacityScale() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rescaleCapacity(t *testing.T) {
+	group := &autoscaling.Group{MinSize: aws.Int64(1), MaxSize: aws.Int64(10), DesiredCapacity: aws.Int64(3)}
+	tests := []struct {
+		name  string
+		scale float64
+		want  *autoscaling.UpdateAutoScalingGroupInput
+	}{
+		{name: "not rescaled", scale: 1, want: &autoscaling.UpdateAutoScalingGroupInput{}},
+		{name: "vcpu", scale: 4, want: &autoscaling.UpdateAutoScalingGroupInput{MinSize: aws.Int64(4), MaxSize: aws.Int64(40), DesiredCapacity: aws.Int64(12)}},
+		{name: "rounded up", scale: 0.25, want: &autoscaling.UpdateAutoScalingGroupInput{MinSize: aws.Int64(1), MaxSize: aws.Int64(3), DesiredCapacity: aws.Int64(1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := &autoscaling.UpdateAutoScalingGroupInput{}
+			rescaleCapacity(group, input, tt.scale)
+			if !reflect.DeepEqual(input, tt.want) {
+				t.Errorf("rescaleCapacity() = %v, want %v", input, tt.want)
+			}
+		})
+	}
+}
+
+func Test_desiredCapacityType(t *testing.T) {
+	tests := []struct {
+		name   string
+		group  *autoscaling.Group
+		config Config
+		want   *string
+	}{
+		{name: "instance types", group: &autoscaling.Group{}, config: Config{Weighting: WeightingVCPU}},
+		{name: "instance types with vcpu desired capacity type", group: &autoscaling.Group{DesiredCapacityType: aws.String(capacityTypeVCPU)}, want: aws.String(capacityTypeUnits)},
+		{name: "instance requirements", group: &autoscaling.Group{}, config: Config{InstanceRequirements: true}, want: aws.String(capacityTypeUnits)},
+		{name: "vcpu instance requirements", group: &autoscaling.Group{}, config: Config{InstanceRequirements: true, Weighting: WeightingVCPU}, want: aws.String(capacityTypeVCPU)},
+		{name: "memory instance requirements", group: &autoscaling.Group{}, config: Config{InstanceRequirements: true, Weighting: WeightingMemory}, want: aws.String(capacityTypeMemory)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := desiredCapacityType(tt.group, tt.config); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("desiredCapacityType() = %v, want %v", aws.StringValue(got), aws.StringValue(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,427 @@
+package autoscaling
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/doitintl/spotzero/aws/ec2"
+)
+
+// missing value in diff output
+const noValue = "-"
+
+// A diffField is a single configuration value compared by Diff
+type diffField struct {
+	name     string
+	current  string
+	proposed string
+}
+
+// Diff returns a human-readable diff between the current EC2 Auto Scaling group configuration
+// and the configuration, that the UpdateAutoScalingGroupInput request would apply:
+// launch template, group capacity, instances distribution and instance type overrides with weights
+// and on-demand prices per capacity unit (for groups with known region). Only values set by the request are compared.
+func Diff(group *autoscaling.Group, input *autoscaling.UpdateAutoScalingGroupInput) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "autoscaling group %v\n", aws.StringValue(group.AutoScalingGroupName))
+	for _, f := range diffFields(group, input) {
+		if f.current == f.proposed {
+			fmt.Fprintf(&sb, "  %s: %s\n", f.name, f.current)
+		} else {
+			fmt.Fprintf(&sb, "~ %s: %s -> %s\n", f.name, f.current, f.proposed)
+		}
+	}
+	var proposed []*autoscaling.LaunchTemplateOverrides
+	if input.MixedInstancesPolicy != nil && input.MixedInstancesPolicy.LaunchTemplate != nil {
+		proposed = input.MixedInstancesPolicy.LaunchTemplate.Overrides
+	} else if input.LaunchTemplate == nil && input.LaunchConfigurationName == nil {
+		// overrides are not changed by the request
+		return sb.String()
+	}
+	sb.WriteString("  instance types:\n")
+	sb.WriteString(diffOverrides(currentOverrides(group), proposed, groupPrices(group)))
+	return sb.String()
+}
+
+// InstancesDistribution values, that EC2 Auto Scaling uses for missing (nil) fields
+const (
+	defaultOnDemandAllocationStrategy          = OnDemandPrioritized
+	defaultOnDemandPercentageAboveBaseCapacity = 100
+	defaultSpotInstancePools                   = 2
+)
+
+// changed returns true if the UpdateAutoScalingGroupInput request changes any value compared by Diff;
+// missing instances distribution values are API defaults, and the overrides order is compared only
+// for prioritized allocation strategies without Spot price ranking (current Spot prices reorder overrides on every run)
+func changed(group *autoscaling.Group, input *autoscaling.UpdateAutoScalingGroupInput, config Config) bool {
+	if proposedTemplate(input) != noValue && currentTemplate(group) != proposedTemplate(input) {
+		return true
+	}
+	if input.CapacityRebalance != nil && aws.BoolValue(input.CapacityRebalance) != aws.BoolValue(group.CapacityRebalance) {
+		return true
+	}
+	if input.MaxInstanceLifetime != nil && aws.Int64Value(input.MaxInstanceLifetime) != aws.Int64Value(group.MaxInstanceLifetime) {
+		return true
+	}
+	if input.DesiredCapacityType != nil && *input.DesiredCapacityType != capacityType(group) {
+		return true
+	}
+	for _, size := range [][2]*int64{
+		{input.MinSize, group.MinSize},
+		{input.MaxSize, group.MaxSize},
+		{input.DesiredCapacity, group.DesiredCapacity},
+	} {
+		if size[0] != nil && *size[0] != aws.Int64Value(size[1]) {
+			return true
+		}
+	}
+	if input.MixedInstancesPolicy == nil {
+		return false
+	}
+	var current *autoscaling.InstancesDistribution
+	if group.MixedInstancesPolicy != nil {
+		current = group.MixedInstancesPolicy.InstancesDistribution
+	}
+	proposed := input.MixedInstancesPolicy.InstancesDistribution
+	if proposed != nil && distributionChanged(current, proposed) {
+		return true
+	}
+	if input.MixedInstancesPolicy.LaunchTemplate == nil {
+		return false
+	}
+	if proposed == nil {
+		proposed = current
+	}
+	ordered := prioritized(proposed) && config.PriceRanking != PriceRankingSpot
+	return overridesChanged(currentOverrides(group), input.MixedInstancesPolicy.LaunchTemplate.Overrides, ordered)
+}
+
+// capacityType returns the group desired capacity type; units if not set
+func capacityType(group *autoscaling.Group) string {
+	if group.DesiredCapacityType == nil {
+		return capacityTypeUnits
+	}
+	return *group.DesiredCapacityType
+}
+
+// distributionChanged compares the instances distributions with API defaults for missing values
+func distributionChanged(current, proposed *autoscaling.InstancesDistribution) bool {
+	if current == nil {
+		current = &autoscaling.InstancesDistribution{}
+	}
+	c, p := distributionValues(current), distributionValues(proposed)
+	// Spot instance pools are used only by the lowest-price Spot allocation strategy
+	if p.SpotAllocationStrategy != SpotLowestPrice {
+		c.SpotInstancePools, p.SpotInstancePools = 0, 0
+	}
+	return c != p
+}
+
+// A distribution is the InstancesDistribution with API defaults for missing values
+type distribution struct {
+	OnDemandAllocationStrategy          string
+	OnDemandBaseCapacity                int64
+	OnDemandPercentageAboveBaseCapacity int64
+	SpotAllocationStrategy              string
+	SpotInstancePools                   int64
+	SpotMaxPrice                        string
+}
+
+func distributionValues(d *autoscaling.InstancesDistribution) distribution {
+	v := distribution{
+		OnDemandAllocationStrategy:          defaultOnDemandAllocationStrategy,
+		OnDemandBaseCapacity:                aws.Int64Value(d.OnDemandBaseCapacity),
+		OnDemandPercentageAboveBaseCapacity: defaultOnDemandPercentageAboveBaseCapacity,
+		SpotAllocationStrategy:              SpotLowestPrice,
+		SpotInstancePools:                   defaultSpotInstancePools,
+		SpotMaxPrice:                        aws.StringValue(d.SpotMaxPrice),
+	}
+	if d.OnDemandAllocationStrategy != nil {
+		v.OnDemandAllocationStrategy = *d.OnDemandAllocationStrategy
+	}
+	if d.OnDemandPercentageAboveBaseCapacity != nil {
+		v.OnDemandPercentageAboveBaseCapacity = *d.OnDemandPercentageAboveBaseCapacity
+	}
+	if d.SpotAllocationStrategy != nil {
+		v.SpotAllocationStrategy = *d.SpotAllocationStrategy
+	}
+	if d.SpotInstancePools != nil {
+		v.SpotInstancePools = *d.SpotInstancePools
+	}
+	return v
+}
+
+// prioritized returns true if the instances distribution launches instances in the overrides order:
+// capacity-optimized-prioritized Spot allocation strategy or prioritized On-Demand instances
+func prioritized(d *autoscaling.InstancesDistribution) bool {
+	if d == nil {
+		d = &autoscaling.InstancesDistribution{}
+	}
+	v := distributionValues(d)
+	if v.SpotAllocationStrategy == SpotCapacityOptimizedPrioritized {
+		return true
+	}
+	onDemand := v.OnDemandBaseCapacity > 0 || v.OnDemandPercentageAboveBaseCapacity > 0
+	return onDemand && v.OnDemandAllocationStrategy == OnDemandPrioritized
+}
+
+// overridesChanged compares instance types (with override launch templates or instance requirements) and weights;
+// the order is compared only if ordered is set
+func overridesChanged(current, proposed []*autoscaling.LaunchTemplateOverrides, ordered bool) bool {
+	if len(current) != len(proposed) {
+		return true
+	}
+	if ordered {
+		for i := range proposed {
+			if !sameOverride(current[i], proposed[i]) {
+				return true
+			}
+		}
+		return false
+	}
+	matched := make([]bool, len(current))
+	for _, p := range proposed {
+		found := false
+		for i, c := range current {
+			if !matched[i] && sameOverride(c, p) {
+				matched[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return true
+		}
+	}
+	return false
+}
+
+func sameOverride(a, b *autoscaling.LaunchTemplateOverrides) bool {
+	return aws.StringValue(a.InstanceType) == aws.StringValue(b.InstanceType) &&
+		weightValue(a.WeightedCapacity) == weightValue(b.WeightedCapacity) &&
+		templateValue(a.LaunchTemplateSpecification) == templateValue(b.LaunchTemplateSpecification) &&
+		reflect.DeepEqual(a.InstanceRequirements, b.InstanceRequirements)
+}
+
+func diffFields(group *autoscaling.Group, input *autoscaling.UpdateAutoScalingGroupInput) []diffField {
+	var fields []diffField
+	if template := proposedTemplate(input); template != noValue {
+		fields = append(fields, diffField{"launch template", currentTemplate(group), template})
+	}
+	if input.CapacityRebalance != nil {
+		fields = append(fields, diffField{"capacity rebalance", boolValue(group.CapacityRebalance), boolValue(input.CapacityRebalance)})
+	}
+	if input.MaxInstanceLifetime != nil {
+		fields = append(fields, diffField{"max instance lifetime", lifetimeValue(group.MaxInstanceLifetime), lifetimeValue(input.MaxInstanceLifetime)})
+	}
+	if input.DesiredCapacityType != nil {
+		current := aws.String(capacityTypeUnits)
+		if group.DesiredCapacityType != nil {
+			current = group.DesiredCapacityType
+		}
+		fields = append(fields, diffField{"desired capacity type", stringValue(current), stringValue(input.DesiredCapacityType)})
+	}
+	if input.MinSize != nil {
+		fields = append(fields, diffField{"min size", int64Value(group.MinSize), int64Value(input.MinSize)})
+	}
+	if input.MaxSize != nil {
+		fields = append(fields, diffField{"max size", int64Value(group.MaxSize), int64Value(input.MaxSize)})
+	}
+	if input.DesiredCapacity != nil {
+		fields = append(fields, diffField{"desired capacity", int64Value(group.DesiredCapacity), int64Value(input.DesiredCapacity)})
+	}
+	if input.MixedInstancesPolicy == nil || input.MixedInstancesPolicy.InstancesDistribution == nil {
+		return fields
+	}
+	current := &autoscaling.InstancesDistribution{}
+	if group.MixedInstancesPolicy != nil && group.MixedInstancesPolicy.InstancesDistribution != nil {
+		current = group.MixedInstancesPolicy.InstancesDistribution
+	}
+	proposed := input.MixedInstancesPolicy.InstancesDistribution
+	return append(fields,
+		diffField{"on-demand allocation strategy", stringValue(current.OnDemandAllocationStrategy), stringValue(proposed.OnDemandAllocationStrategy)},
+		diffField{"on-demand base capacity", int64Value(current.OnDemandBaseCapacity), int64Value(proposed.OnDemandBaseCapacity)},
+		diffField{"on-demand percentage above base capacity", int64Value(current.OnDemandPercentageAboveBaseCapacity), int64Value(proposed.OnDemandPercentageAboveBaseCapacity)},
+		diffField{"spot allocation strategy", stringValue(current.SpotAllocationStrategy), stringValue(proposed.SpotAllocationStrategy)},
+		diffField{"spot instance pools", int64Value(current.SpotInstancePools), int64Value(proposed.SpotInstancePools)},
+		diffField{"spot max price", stringValue(current.SpotMaxPrice), stringValue(proposed.SpotMaxPrice)},
+	)
+}
+
+// groupPrices returns on-demand prices in the group region; nil if the region is unknown
+func groupPrices(group *autoscaling.Group) map[string]float64 {
+	groupArn, err := arn.Parse(aws.StringValue(group.AutoScalingGroupARN))
+	if err != nil {
+		return nil
+	}
+	return ec2.GetOnDemandPrices(groupArn.Region)
+}
+
+// diffOverrides compares instance types and weights; added, changed and kept types are listed in the proposed order
+// with the on-demand price per capacity unit, if known
+func diffOverrides(current, proposed []*autoscaling.LaunchTemplateOverrides, prices map[string]float64) string {
+	var sb strings.Builder
+	weights := make(map[string]string, len(current))
+	for _, o := range current {
+		weights[overrideName(o)] = weightValue(o.WeightedCapacity)
+	}
+	kept := make(map[string]bool, len(proposed))
+	for _, o := range proposed {
+		instanceType := overrideName(o)
+		weight := weightValue(o.WeightedCapacity)
+		price := diffPrice(o, prices)
+		kept[instanceType] = true
+		currentWeight, ok := weights[instanceType]
+		switch {
+		case !ok:
+			fmt.Fprintf(&sb, "+   %s (weight %s%s)\n", instanceType, weight, price)
+		case currentWeight != weight:
+			fmt.Fprintf(&sb, "~   %s (weight %s -> %s%s)\n", instanceType, currentWeight, weight, price)
+		default:
+			fmt.Fprintf(&sb, "    %s (weight %s%s)\n", instanceType, weight, price)
+		}
+	}
+	for _, o := range current {
+		if instanceType := overrideName(o); !kept[instanceType] {
+			fmt.Fprintf(&sb, "-   %s (weight %s%s)\n", instanceType, weightValue(o.WeightedCapacity), diffPrice(o, prices))
+		}
+	}
+	return sb.String()
+}
+
+// diffPrice formats the on-demand price per capacity unit of the override; empty if the price is unknown
+func diffPrice(o *autoscaling.LaunchTemplateOverrides, prices map[string]float64) string {
+	price, ok := unitPrice(o, prices)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(", on-demand %s per unit hour", priceValue(price, ok))
+}
+
+// overrideName returns the override instance type (with override launch template) or instance requirements summary
+func overrideName(o *autoscaling.LaunchTemplateOverrides) string {
+	r := o.InstanceRequirements
+	if r == nil && o.LaunchTemplateSpecification != nil {
+		return fmt.Sprintf("%s [%s]", aws.StringValue(o.InstanceType), templateValue(o.LaunchTemplateSpecification))
+	}
+	if r == nil {
+		return aws.StringValue(o.InstanceType)
+	}
+	attributes := []string{
+		fmt.Sprintf("vcpu %s", rangeValue(r.VCpuCount.Min, r.VCpuCount.Max)),
+		fmt.Sprintf("memory %s MiB", rangeValue(r.MemoryMiB.Min, r.MemoryMiB.Max)),
+	}
+	if m := r.MemoryGiBPerVCpu; m != nil {
+		attributes = append(attributes, fmt.Sprintf("memory per vcpu %v-%v GiB", aws.Float64Value(m.Min), aws.Float64Value(m.Max)))
+	}
+	if len(r.CpuManufacturers) > 0 {
+		attributes = append(attributes, "cpu "+strings.Join(aws.StringValueSlice(r.CpuManufacturers), ","))
+	}
+	if len(r.InstanceGenerations) > 0 {
+		attributes = append(attributes, "generations "+strings.Join(aws.StringValueSlice(r.InstanceGenerations), ","))
+	}
+	if r.BurstablePerformance != nil {
+		attributes = append(attributes, "burstable "+*r.BurstablePerformance)
+	}
+	if r.BareMetal != nil {
+		attributes = append(attributes, "bare metal "+*r.BareMetal)
+	}
+	if r.LocalStorage != nil {
+		attributes = append(attributes, "local storage "+*r.LocalStorage)
+	}
+	if s := r.TotalLocalStorageGB; s != nil {
+		attributes = append(attributes, fmt.Sprintf("local storage %v+ GB", aws.Float64Value(s.Min)))
+	}
+	if b := r.BaselineEbsBandwidthMbps; b != nil {
+		attributes = append(attributes, fmt.Sprintf("ebs bandwidth %s Mbps", rangeValue(b.Min, b.Max)))
+	}
+	if a := r.AcceleratorCount; a != nil {
+		attributes = append(attributes, fmt.Sprintf("accelerators %s", rangeValue(a.Min, a.Max)))
+	}
+	return fmt.Sprintf("instance requirements [%s]", strings.Join(attributes, ", "))
+}
+
+// rangeValue formats min-max range; missing min is 0 and missing max is unlimited
+func rangeValue(min, max *int64) string {
+	if max == nil {
+		return fmt.Sprintf("%d+", aws.Int64Value(min))
+	}
+	return fmt.Sprintf("%d-%d", aws.Int64Value(min), *max)
+}
+
+func currentOverrides(group *autoscaling.Group) []*autoscaling.LaunchTemplateOverrides {
+	if group.MixedInstancesPolicy == nil || group.MixedInstancesPolicy.LaunchTemplate == nil {
+		return nil
+	}
+	return group.MixedInstancesPolicy.LaunchTemplate.Overrides
+}
+
+func currentTemplate(group *autoscaling.Group) string {
+	if group.LaunchConfigurationName != nil {
+		return fmt.Sprintf("launch configuration %v", *group.LaunchConfigurationName)
+	}
+	spec, err := launchTemplateSpec(group)
+	if err != nil {
+		return noValue
+	}
+	return templateValue(spec)
+}
+
+func proposedTemplate(input *autoscaling.UpdateAutoScalingGroupInput) string {
+	switch {
+	case input.LaunchConfigurationName != nil:
+		return fmt.Sprintf("launch configuration %v", *input.LaunchConfigurationName)
+	case input.LaunchTemplate != nil:
+		return templateValue(input.LaunchTemplate)
+	case input.MixedInstancesPolicy != nil && input.MixedInstancesPolicy.LaunchTemplate != nil:
+		return templateValue(input.MixedInstancesPolicy.LaunchTemplate.LaunchTemplateSpecification)
+	}
+	return noValue
+}
+
+func templateValue(spec *autoscaling.LaunchTemplateSpecification) string {
+	if spec == nil {
+		return noValue
+	}
+	name := aws.StringValue(spec.LaunchTemplateId)
+	if name == "" {
+		name = aws.StringValue(spec.LaunchTemplateName)
+	}
+	return fmt.Sprintf("%s (version %s)", name, stringValue(spec.Version))
+}
+
+func weightValue(weight *string) string {
+	if weight == nil {
+		return "1"
+	}
+	return *weight
+}
+
+func stringValue(v *string) string {
+	if v == nil {
+		return noValue
+	}
+	return *v
+}
+
+func int64Value(v *int64) string {
+	if v == nil {
+		return noValue
+	}
+	return fmt.Sprint(*v)
+}
+
+func boolValue(v *bool) string {
+	return fmt.Sprint(aws.BoolValue(v))
+}
+
+// lifetimeValue formats maximum instance lifetime in seconds; 0 means not set
+func lifetimeValue(v *int64) string {
+	if aws.Int64Value(v) == 0 {
+		return noValue
+	}
+	return fmt.Sprintf("%ds", *v)
+}
@@ -0,0 +1,252 @@
+package autoscaling
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+func testOverrides(typeWeights ...string) []*autoscaling.LaunchTemplateOverrides {
+	overrides := make([]*autoscaling.LaunchTemplateOverrides, 0, len(typeWeights)/2)
+	for i := 0; i+1 < len(typeWeights); i += 2 {
+		overrides = append(overrides, &autoscaling.LaunchTemplateOverrides{
+			InstanceType:     aws.String(typeWeights[i]),
+			WeightedCapacity: aws.String(typeWeights[i+1]),
+		})
+	}
+	return overrides
+}
+
+//nolint:funlen
+func TestDiff(t *testing.T) {
+	lt := &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-1"), Version: aws.String("1")}
+	proposed := &autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: aws.String("test-asg"),
+		MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{
+			InstancesDistribution: &autoscaling.InstancesDistribution{
+				OnDemandBaseCapacity:                aws.Int64(0),
+				OnDemandPercentageAboveBaseCapacity: aws.Int64(0),
+				SpotAllocationStrategy:              aws.String(SpotCapacityOptimized),
+			},
+			LaunchTemplate: &autoscaling.LaunchTemplate{
+				LaunchTemplateSpecification: lt,
+				Overrides:                   testOverrides("m5.large", "2", "m5.xlarge", "4", "m4.large", "2"),
+			},
+		},
+	}
+	tests := []struct {
+		name  string
+		group *autoscaling.Group
+		input *autoscaling.UpdateAutoScalingGroupInput
+		want  string
+	}{
+		{
+			name:  "launch template group",
+			group: &autoscaling.Group{AutoScalingGroupName: aws.String("test-asg"), LaunchTemplate: lt},
+			input: proposed,
+			want: `autoscaling group test-asg
+  launch template: lt-1 (version 1)
+  on-demand allocation strategy: -
+~ on-demand base capacity: - -> 0
+~ on-demand percentage above base capacity: - -> 0
+~ spot allocation strategy: - -> capacity-optimized
+  spot instance pools: -
+  spot max price: -
+  instance types:
++   m5.large (weight 2)
++   m5.xlarge (weight 4)
++   m4.large (weight 2)
+`,
+		},
+		{
+			name: "mixed instances policy group",
+			group: &autoscaling.Group{
+				AutoScalingGroupName: aws.String("test-asg"),
+				MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{
+					InstancesDistribution: &autoscaling.InstancesDistribution{
+						OnDemandBaseCapacity:                aws.Int64(0),
+						OnDemandPercentageAboveBaseCapacity: aws.Int64(100),
+						SpotAllocationStrategy:              aws.String("lowest-price"),
+						SpotInstancePools:                   aws.Int64(2),
+					},
+					LaunchTemplate: &autoscaling.LaunchTemplate{
+						LaunchTemplateSpecification: &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-1"), Version: aws.String("$Latest")},
+						Overrides:                   testOverrides("m5.large", "1", "m5.xlarge", "4", "c5.large", "2"),
+					},
+				},
+			},
+			input: proposed,
+			want: `autoscaling group test-asg
+~ launch template: lt-1 (version $Latest) -> lt-1 (version 1)
+  on-demand allocation strategy: -
+  on-demand base capacity: 0
+~ on-demand percentage above base capacity: 100 -> 0
+~ spot allocation strategy: lowest-price -> capacity-optimized
+~ spot instance pools: 2 -> -
+  spot max price: -
+  instance types:
+~   m5.large (weight 1 -> 2)
+    m5.xlarge (weight 4)
++   m4.large (weight 2)
+-   c5.large (weight 2)
+`,
+		},
+		{
+			name: "capacity rebalance and max instance lifetime",
+			group: &autoscaling.Group{
+				AutoScalingGroupName: aws.String("test-asg"),
+				MaxInstanceLifetime:  aws.Int64(86400),
+				LaunchTemplate:       lt,
+			},
+			input: &autoscaling.UpdateAutoScalingGroupInput{
+				AutoScalingGroupName: aws.String("test-asg"),
+				CapacityRebalance:    aws.Bool(true),
+				MaxInstanceLifetime:  aws.Int64(604800),
+			},
+			want: `autoscaling group test-asg
+~ capacity rebalance: false -> true
+~ max instance lifetime: 86400s -> 604800s
+`,
+		},
+		{
+			name: "rescaled capacity",
+			group: &autoscaling.Group{
+				AutoScalingGroupName: aws.String("test-asg"),
+				MinSize:              aws.Int64(1),
+				MaxSize:              aws.Int64(10),
+				DesiredCapacity:      aws.Int64(2),
+				LaunchTemplate:       lt,
+			},
+			input: &autoscaling.UpdateAutoScalingGroupInput{
+				AutoScalingGroupName: aws.String("test-asg"),
+				DesiredCapacityType:  aws.String("vcpu"),
+				MinSize:              aws.Int64(2),
+				MaxSize:              aws.Int64(20),
+				DesiredCapacity:      aws.Int64(4),
+			},
+			want: `autoscaling group test-asg
+~ desired capacity type: units -> vcpu
+~ min size: 1 -> 2
+~ max size: 10 -> 20
+~ desired capacity: 2 -> 4
+`,
+		},
+		{
+			name: "instance requirements",
+			group: &autoscaling.Group{
+				AutoScalingGroupName: aws.String("test-asg"),
+				MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{
+					LaunchTemplate: &autoscaling.LaunchTemplate{
+						LaunchTemplateSpecification: lt,
+						Overrides:                   testOverrides("m5.large", "2"),
+					},
+				},
+			},
+			input: &autoscaling.UpdateAutoScalingGroupInput{
+				AutoScalingGroupName: aws.String("test-asg"),
+				MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{
+					LaunchTemplate: &autoscaling.LaunchTemplate{
+						LaunchTemplateSpecification: lt,
+						Overrides: []*autoscaling.LaunchTemplateOverrides{{
+							InstanceRequirements: &autoscaling.InstanceRequirements{
+								VCpuCount:            &autoscaling.VCpuCountRequest{Min: aws.Int64(1), Max: aws.Int64(4)},
+								MemoryMiB:            &autoscaling.MemoryMiBRequest{Min: aws.Int64(4096)},
+								CpuManufacturers:     aws.StringSlice([]string{"intel", "amd"}),
+								BurstablePerformance: aws.String("excluded"),
+							},
+						}},
+					},
+				},
+			},
+			want: `autoscaling group test-asg
+  launch template: lt-1 (version 1)
+  instance types:
++   instance requirements [vcpu 1-4, memory 4096+ MiB, cpu intel,amd, burstable excluded] (weight 1)
+-   m5.large (weight 2)
+`,
+		},
+		{
+			name: "on-demand prices per unit",
+			group: &autoscaling.Group{
+				AutoScalingGroupName: aws.String("test-asg"),
+				AutoScalingGroupARN:  aws.String("arn:aws:autoscaling:us-east-1:123456789012:autoScalingGroup:uuid:autoScalingGroupName/test-asg"),
+				MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{
+					LaunchTemplate: &autoscaling.LaunchTemplate{
+						LaunchTemplateSpecification: lt,
+						Overrides:                   testOverrides("m5.large", "1", "c5.large", "2"),
+					},
+				},
+			},
+			input: &autoscaling.UpdateAutoScalingGroupInput{
+				AutoScalingGroupName: aws.String("test-asg"),
+				MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{
+					LaunchTemplate: &autoscaling.LaunchTemplate{
+						LaunchTemplateSpecification: lt,
+						Overrides:                   testOverrides("m5.large", "2", "m4.large", "2", "x0.tiny", "1"),
+					},
+				},
+			},
+			want: `autoscaling group test-asg
+  launch template: lt-1 (version 1)
+  instance types:
+~   m5.large (weight 1 -> 2, on-demand $0.04800 per unit hour)
++   m4.large (weight 2, on-demand $0.05000 per unit hour)
++   x0.tiny (weight 1)
+-   c5.large (weight 2, on-demand $0.04250 per unit hour)
+`,
+		},
+		{
+			name: "restore launch configuration",
+			group: &autoscaling.Group{
+				AutoScalingGroupName: aws.String("test-asg"),
+				MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{
+					LaunchTemplate: &autoscaling.LaunchTemplate{
+						LaunchTemplateSpecification: lt,
+						Overrides:                   testOverrides("m5.large", "2"),
+					},
+				},
+			},
+			input: &autoscaling.UpdateAutoScalingGroupInput{
+				AutoScalingGroupName:    aws.String("test-asg"),
+				LaunchConfigurationName: aws.String("test-lc"),
+			},
+			want: `autoscaling group test-asg
+~ launch template: lt-1 (version 1) -> launch configuration test-lc
+  instance types:
+-   m5.large (weight 2)
+`,
+		},
+		{
+			name: "migrate launch configuration",
+			group: &autoscaling.Group{
+				AutoScalingGroupName:    aws.String("test-asg"),
+				LaunchConfigurationName: aws.String("test-lc"),
+			},
+			input: &autoscaling.UpdateAutoScalingGroupInput{
+				AutoScalingGroupName: aws.String("test-asg"),
+				MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{
+					LaunchTemplate: &autoscaling.LaunchTemplate{
+						LaunchTemplateSpecification: &autoscaling.LaunchTemplateSpecification{
+							LaunchTemplateName: aws.String("spotzero-test-lc"),
+							Version:            aws.String("1"),
+						},
+						Overrides: testOverrides("m5.large", "2"),
+					},
+				},
+			},
+			want: `autoscaling group test-asg
+~ launch template: launch configuration test-lc -> spotzero-test-lc (version 1)
+  instance types:
++   m5.large (weight 2)
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.group, tt.input); got != tt.want {
+				t.Errorf("Diff() = \n%v, want \n%v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package autoscaling
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/doitintl/spotzero/aws/ec2"
+)
+
+// migrateLaunchConfiguration creates an EC2 launch template equivalent to the group launch configuration.
+// It returns a copy of the group with the created launch template attached instead of the launch configuration.
+func (s *asgUpdaterService) migrateLaunchConfiguration(ctx context.Context, group *autoscaling.Group) (*autoscaling.Group, error) {
+	log.Printf("migrating launch configuration %v of the autoscaling group %v", *group.LaunchConfigurationName, *group.AutoScalingGroupARN)
+	lc, err := s.describeLaunchConfiguration(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := s.ltsvc.CreateFromLaunchConfiguration(ctx, lc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create launch template from launch configuration: %v", err)
+	}
+	log.Printf("using launch template %v version %v for the autoscaling group %v", *spec.LaunchTemplateId, *spec.Version, *group.AutoScalingGroupARN)
+	return migratedGroup(group, spec), nil
+}
+
+// previewLaunchConfiguration returns a copy of the group with the launch template, that migrateLaunchConfiguration attaches,
+// and the instance details of the launch configuration; the launch template is not created
+func (s *asgUpdaterService) previewLaunchConfiguration(ctx context.Context, group *autoscaling.Group) (*autoscaling.Group, *ec2.InstanceDetails, error) {
+	lc, err := s.describeLaunchConfiguration(ctx, group)
+	if err != nil {
+		return nil, nil, err
+	}
+	spec, err := s.ltsvc.PreviewFromLaunchConfiguration(ctx, lc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get launch template for launch configuration: %v", err)
+	}
+	return migratedGroup(group, spec), ec2.LaunchConfigurationInstance(lc), nil
+}
+
+func (s *asgUpdaterService) describeLaunchConfiguration(ctx context.Context, group *autoscaling.Group) (*autoscaling.LaunchConfiguration, error) {
+	output, err := s.asgsvc.DescribeLaunchConfigurationsWithContext(ctx, &autoscaling.DescribeLaunchConfigurationsInput{
+		LaunchConfigurationNames: []*string{group.LaunchConfigurationName},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error describing launch configuration: %v", err)
+	}
+	if len(output.LaunchConfigurations) != 1 {
+		return nil, fmt.Errorf("expected to get a single launch configuration %v", *group.LaunchConfigurationName)
+	}
+	return output.LaunchConfigurations[0], nil
+}
+
+// migratedGroup returns a copy of the group with the launch template attached instead of the launch configuration
+func migratedGroup(group *autoscaling.Group, spec *autoscaling.LaunchTemplateSpecification) *autoscaling.Group {
+	migrated := *group
+	migrated.LaunchConfigurationName = nil
+	migrated.LaunchTemplate = spec
+	return &migrated
+}
@@ -0,0 +1,147 @@
+package autoscaling
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/doitintl/spotzero/aws/ec2"
+	"github.com/doitintl/spotzero/mocks"
+	ec2mocks "github.com/doitintl/spotzero/mocks/ec2"
+)
+
+func Test_asgUpdaterService_migrateLaunchConfiguration(t *testing.T) {
+	lc := &autoscaling.LaunchConfiguration{
+		LaunchConfigurationName: aws.String("test-lc"),
+		ImageId:                 aws.String("ami-1"),
+		InstanceType:            aws.String("m5.large"),
+	}
+	spec := &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-1"), Version: aws.String("1")}
+	tests := []struct {
+		name          string
+		configs       []*autoscaling.LaunchConfiguration
+		describeErr   error
+		createErr     error
+		want          *autoscaling.LaunchTemplateSpecification
+		wantErr       bool
+		wantLtCreated bool
+	}{
+		{
+			name:          "migrate launch configuration",
+			configs:       []*autoscaling.LaunchConfiguration{lc},
+			want:          spec,
+			wantLtCreated: true,
+		},
+		{
+			name:        "fail: describe launch configuration",
+			describeErr: errors.New("test"),
+			wantErr:     true,
+		},
+		{
+			name:    "fail: launch configuration not found",
+			wantErr: true,
+		},
+		{
+			name:          "fail: create launch template",
+			configs:       []*autoscaling.LaunchConfiguration{lc},
+			createErr:     errors.New("test"),
+			wantErr:       true,
+			wantLtCreated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			group := &autoscaling.Group{
+				AutoScalingGroupARN:     aws.String("test-asg-arn"),
+				AutoScalingGroupName:    aws.String("test-asg"),
+				LaunchConfigurationName: aws.String("test-lc"),
+			}
+			mockAsgSvc := new(mocks.AwsAsgUpdater)
+			mockLtSvc := new(ec2mocks.LaunchTemplateCreator)
+			s := &asgUpdaterService{asgsvc: mockAsgSvc, ltsvc: mockLtSvc}
+			mockAsgSvc.On("DescribeLaunchConfigurationsWithContext", ctx, &autoscaling.DescribeLaunchConfigurationsInput{
+				LaunchConfigurationNames: aws.StringSlice([]string{"test-lc"}),
+			}).Return(&autoscaling.DescribeLaunchConfigurationsOutput{LaunchConfigurations: tt.configs}, tt.describeErr).Once()
+			if tt.wantLtCreated {
+				mockLtSvc.On("CreateFromLaunchConfiguration", ctx, lc).Return(tt.want, tt.createErr).Once()
+			}
+			got, err := s.migrateLaunchConfiguration(ctx, group)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("migrateLaunchConfiguration() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil {
+				if got.LaunchConfigurationName != nil || !reflect.DeepEqual(got.LaunchTemplate, tt.want) {
+					t.Errorf("migrateLaunchConfiguration() got = %v, want launch template %v", got, tt.want)
+				}
+				// the original group keeps the launch configuration for the snapshot
+				if group.LaunchConfigurationName == nil {
+					t.Error("migrateLaunchConfiguration() modified the original group")
+				}
+			}
+			mockAsgSvc.AssertExpectations(t)
+			mockLtSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_asgUpdaterService_previewLaunchConfiguration(t *testing.T) {
+	lc := &autoscaling.LaunchConfiguration{
+		LaunchConfigurationName: aws.String("test-lc"),
+		ImageId:                 aws.String("ami-1"),
+		InstanceType:            aws.String("m5.large"),
+	}
+	spec := &autoscaling.LaunchTemplateSpecification{LaunchTemplateName: aws.String("spotzero-test-lc"), Version: aws.String("1")}
+	tests := []struct {
+		name       string
+		previewErr error
+		want       *autoscaling.LaunchTemplateSpecification
+		wantErr    bool
+	}{
+		{
+			name: "preview launch template",
+			want: spec,
+		},
+		{
+			name:       "fail: describe launch template",
+			previewErr: errors.New("test"),
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			group := &autoscaling.Group{
+				AutoScalingGroupARN:     aws.String("test-asg-arn"),
+				AutoScalingGroupName:    aws.String("test-asg"),
+				LaunchConfigurationName: aws.String("test-lc"),
+			}
+			mockAsgSvc := new(mocks.AwsAsgUpdater)
+			mockLtSvc := new(ec2mocks.LaunchTemplateCreator)
+			s := &asgUpdaterService{asgsvc: mockAsgSvc, ltsvc: mockLtSvc}
+			mockAsgSvc.On("DescribeLaunchConfigurationsWithContext", ctx, &autoscaling.DescribeLaunchConfigurationsInput{
+				LaunchConfigurationNames: aws.StringSlice([]string{"test-lc"}),
+			}).Return(&autoscaling.DescribeLaunchConfigurationsOutput{LaunchConfigurations: []*autoscaling.LaunchConfiguration{lc}}, nil).Once()
+			mockLtSvc.On("PreviewFromLaunchConfiguration", ctx, lc).Return(tt.want, tt.previewErr).Once()
+			got, instance, err := s.previewLaunchConfiguration(ctx, group)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("previewLaunchConfiguration() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil {
+				if got.LaunchConfigurationName != nil || !reflect.DeepEqual(got.LaunchTemplate, tt.want) {
+					t.Errorf("previewLaunchConfiguration() got = %v, want launch template %v", got, tt.want)
+				}
+				if want := (&ec2.InstanceDetails{TypeName: "m5.large", MarketType: ec2.OnDemandMarketType}); !reflect.DeepEqual(instance, want) {
+					t.Errorf("previewLaunchConfiguration() instance = %v, want %v", instance, want)
+				}
+			}
+			mockAsgSvc.AssertExpectations(t)
+			mockLtSvc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package autoscaling
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/doitintl/spotzero/internal/math"
+)
+
+const (
+	maxAsgNamesPerDescribe = 50
+	// ARN resource prefix of the EC2 Auto Scaling group name
+	asgArnNamePrefix = "autoScalingGroupName/"
+)
+
+// A NameMatcher matches EC2 Auto Scaling groups against exact names, ARNs, globs and regular expressions.
+// An empty NameMatcher has no patterns.
+type NameMatcher struct {
+	patterns []string
+	names    []string
+	exact    map[string]bool
+	arns     map[string]bool
+	globs    []string
+	regexps  []*regexp.Regexp
+}
+
+// ParseNameMatcher parses EC2 Auto Scaling group name patterns:
+//  web-1                                  exact name
+//  arn:aws:autoscaling:...:autoScalingGroupName/web-1   group ARN; matches only the group in its account and Region
+//  web-*                                  glob; `*` matches any sequence of characters
+//  /^web-[0-9]+$/                         regular expression
+// It returns an error for an invalid regular expression.
+func ParseNameMatcher(patterns []string) (NameMatcher, error) {
+	m := NameMatcher{exact: make(map[string]bool), arns: make(map[string]bool)}
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		switch {
+		case p == "":
+			continue
+		case len(p) > 1 && strings.HasPrefix(p, "/") && strings.HasSuffix(p, "/"):
+			re, err := regexp.Compile(p[1 : len(p)-1])
+			if err != nil {
+				return NameMatcher{}, fmt.Errorf("invalid autoscaling group name pattern %q: %v", p, err)
+			}
+			m.regexps = append(m.regexps, re)
+		case strings.HasPrefix(p, "arn:"):
+			i := strings.Index(p, asgArnNamePrefix)
+			if i < 0 {
+				return NameMatcher{}, fmt.Errorf("invalid autoscaling group ARN %q", p)
+			}
+			m.arns[p] = true
+			m.addBatchName(p[i+len(asgArnNamePrefix):])
+		case strings.Contains(p, wildcard):
+			m.globs = append(m.globs, p)
+		default:
+			m.exact[p] = true
+			m.addBatchName(p)
+		}
+		m.patterns = append(m.patterns, p)
+	}
+	return m, nil
+}
+
+// addBatchName adds the group name requested by nameBatches
+func (m *NameMatcher) addBatchName(name string) {
+	for _, n := range m.names {
+		if n == name {
+			return
+		}
+	}
+	m.names = append(m.names, name)
+}
+
+// LoadNamePatterns reads EC2 Auto Scaling group name patterns from the file: one pattern per line,
+// empty lines and lines starting with `#` are skipped.
+func LoadNamePatterns(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening names file: %v", err)
+	}
+	defer f.Close()
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading names file: %v", err)
+	}
+	return patterns, nil
+}
+
+// Empty returns true if NameMatcher has no patterns
+func (m NameMatcher) Empty() bool {
+	return len(m.patterns) == 0
+}
+
+// Matches returns true if the EC2 Auto Scaling group name or ARN matches any pattern
+func (m NameMatcher) Matches(group *autoscaling.Group) bool {
+	if m.arns[aws.StringValue(group.AutoScalingGroupARN)] {
+		return true
+	}
+	name := aws.StringValue(group.AutoScalingGroupName)
+	if m.exact[name] {
+		return true
+	}
+	for _, g := range m.globs {
+		if matchValue(g, name) {
+			return true
+		}
+	}
+	for _, re := range m.regexps {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns comma separated patterns
+func (m NameMatcher) String() string {
+	return strings.Join(m.patterns, ",")
+}
+
+// nameBatches returns batches of exact names for DescribeAutoScalingGroups request.
+// A single nil batch is returned, if NameMatcher is empty or has globs or regular expressions (full scan).
+func (m NameMatcher) nameBatches() [][]*string {
+	if m.Empty() || len(m.globs) > 0 || len(m.regexps) > 0 {
+		return [][]*string{nil}
+	}
+	var batches [][]*string
+	for i := 0; i < len(m.names); i += maxAsgNamesPerDescribe {
+		batches = append(batches, aws.StringSlice(m.names[i:math.MinInt(i+maxAsgNamesPerDescribe, len(m.names))]))
+	}
+	return batches
+}
+
+// A GroupFilter selects EC2 Auto Scaling groups by tags and names
+type GroupFilter struct {
+	// Tags tag selector; empty selector matches all groups
+	Tags Selector
+	// Names include only groups with matching names; empty matcher matches all groups
+	Names NameMatcher
+	// ExcludeNames exclude groups with matching names
+	ExcludeNames NameMatcher
+	// LaunchConfigurations list groups with launch configuration as eligible for update (migration to launch template)
+	LaunchConfigurations bool
+	// Policy exclude groups matching policy rules with exclude flag
+	Policy *Policy
+	// ReoptimizeAfter list groups updated by spotzero longer than this duration ago as eligible for update; 0 skips updated groups
+	ReoptimizeAfter time.Duration
+}
+
+// Matches returns true if the EC2 Auto Scaling group matches the GroupFilter
+func (f GroupFilter) Matches(group *autoscaling.Group) bool {
+	return f.selects(group) && f.exclusion(group) == ""
+}
+
+// selects returns true if the EC2 Auto Scaling group matches tags and names
+func (f GroupFilter) selects(group *autoscaling.Group) bool {
+	if !f.Names.Empty() && !f.Names.Matches(group) {
+		return false
+	}
+	return f.Tags.Matches(group.Tags)
+}
+
+// exclusion returns why the EC2 Auto Scaling group is excluded by name or policy rule; empty string if not excluded
+func (f GroupFilter) exclusion(group *autoscaling.Group) string {
+	if f.ExcludeNames.Matches(group) {
+		return "autoscaling group name is excluded"
+	}
+	if rule := f.Policy.Match(group); rule != nil && rule.Exclude {
+		return fmt.Sprintf("autoscaling group is excluded by config rule %q", rule.Name)
+	}
+	return ""
+}
+
+// migrates returns true if the EC2 Auto Scaling group launch configuration is migrated to launch template on update
+func (f GroupFilter) migrates(group *autoscaling.Group) bool {
+	if rule := f.Policy.Match(group); rule != nil && rule.Config.MigrateLaunchConfigurations != nil {
+		return *rule.Config.MigrateLaunchConfigurations
+	}
+	return f.LaunchConfigurations
+}
+
+// String returns GroupFilter description
+func (f GroupFilter) String() string {
+	var parts []string
+	if !f.Tags.Empty() {
+		parts = append(parts, fmt.Sprintf("tags: %v", f.Tags))
+	}
+	if !f.Names.Empty() {
+		parts = append(parts, fmt.Sprintf("names: %v", f.Names))
+	}
+	if !f.ExcludeNames.Empty() {
+		parts = append(parts, fmt.Sprintf("excluded names: %v", f.ExcludeNames))
+	}
+	if len(parts) == 0 {
+		return "all"
+	}
+	return strings.Join(parts, "; ")
+}
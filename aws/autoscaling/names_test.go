@@ -0,0 +1,127 @@
+package autoscaling
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+const testWebArn = "arn:aws:autoscaling:us-east-1:123456789012:autoScalingGroup:uuid:autoScalingGroupName/web-1"
+
+func TestNameMatcher_Matches(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		group    string
+		arn      string
+		want     bool
+		wantErr  bool
+	}{
+		{name: "exact name", patterns: []string{"web-1", "web-2"}, group: "web-2", want: true},
+		{name: "exact name should fail", patterns: []string{"web-1"}, group: "web-10", want: false},
+		{name: "group ARN", patterns: []string{testWebArn}, group: "web-1", arn: testWebArn, want: true},
+		{
+			name:     "group ARN should fail for the same name in another region",
+			patterns: []string{testWebArn},
+			group:    "web-1",
+			arn:      "arn:aws:autoscaling:eu-west-1:123456789012:autoScalingGroup:uuid:autoScalingGroupName/web-1",
+			want:     false,
+		},
+		{name: "glob", patterns: []string{"web-*"}, group: "web-10", want: true},
+		{name: "glob should fail", patterns: []string{"web-*"}, group: "api-1", want: false},
+		{name: "regular expression", patterns: []string{"/^web-[0-9]+$/"}, group: "web-10", want: true},
+		{name: "regular expression should fail", patterns: []string{"/^web-[0-9]+$/"}, group: "web-a", want: false},
+		{name: "empty matcher should fail", patterns: []string{" "}, group: "web-1", want: false},
+		{name: "fail: invalid regular expression", patterns: []string{"/web-[/"}, wantErr: true},
+		{name: "fail: invalid ARN", patterns: []string{"arn:aws:autoscaling:us-east-1:123456789012:web-1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseNameMatcher(tt.patterns)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseNameMatcher() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			group := &autoscaling.Group{AutoScalingGroupName: aws.String(tt.group), AutoScalingGroupARN: aws.String(tt.arn)}
+			if got := m.Matches(group); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupFilter_Matches_arnAcrossRegions(t *testing.T) {
+	groups := []*autoscaling.Group{
+		{AutoScalingGroupName: aws.String("web-1"), AutoScalingGroupARN: aws.String(testWebArn)},
+		{
+			AutoScalingGroupName: aws.String("web-1"),
+			AutoScalingGroupARN:  aws.String("arn:aws:autoscaling:eu-west-1:123456789012:autoScalingGroup:uuid:autoScalingGroupName/web-1"),
+		},
+	}
+	tests := []struct {
+		name    string
+		names   []string
+		exclude []string
+		want    []bool
+	}{
+		{name: "include by ARN", names: []string{testWebArn}, want: []bool{true, false}},
+		{name: "exclude by ARN", exclude: []string{testWebArn}, want: []bool{false, true}},
+		{name: "include by name", names: []string{"web-1"}, want: []bool{true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var filter GroupFilter
+			var err error
+			if filter.Names, err = ParseNameMatcher(tt.names); err != nil {
+				t.Fatalf("ParseNameMatcher() error = %v", err)
+			}
+			if filter.ExcludeNames, err = ParseNameMatcher(tt.exclude); err != nil {
+				t.Fatalf("ParseNameMatcher() error = %v", err)
+			}
+			for i, group := range groups {
+				if got := filter.Matches(group); got != tt.want[i] {
+					t.Errorf("Matches(%v) = %v, want %v", *group.AutoScalingGroupARN, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNameMatcher_nameBatches(t *testing.T) {
+	names := make([]string, maxAsgNamesPerDescribe+1)
+	for i := range names {
+		names[i] = fmt.Sprint("asg-", i)
+	}
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []int
+	}{
+		{name: "empty matcher", want: []int{0}},
+		{name: "names with glob", patterns: []string{"asg-1", "web-*"}, want: []int{0}},
+		{name: "duplicate names", patterns: []string{"asg-1", "asg-1"}, want: []int{1}},
+		{name: "name and ARN", patterns: []string{"web-1", testWebArn}, want: []int{1}},
+		{name: "multiple batches", patterns: names, want: []int{maxAsgNamesPerDescribe, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseNameMatcher(tt.patterns)
+			if err != nil {
+				t.Errorf("ParseNameMatcher() error = %v", err)
+				return
+			}
+			got := m.nameBatches()
+			if len(got) != len(tt.want) {
+				t.Errorf("nameBatches() batches = %v, want %v", len(got), len(tt.want))
+				return
+			}
+			for i := range got {
+				if len(got[i]) != tt.want[i] {
+					t.Errorf("nameBatches() batch size = %v, want %v", len(got[i]), tt.want[i])
+				}
+			}
+		})
+	}
+}
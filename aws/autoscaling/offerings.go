@@ -0,0 +1,88 @@
+package autoscaling
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+// zoneOfferings instance types offered in the autoscaling group Availability Zones
+type zoneOfferings struct {
+	zones []string
+	// instance type -> Availability Zones of the group offering it
+	offered map[string][]string
+	// drop instance types, that are not offered in every zone
+	requireAll bool
+}
+
+// available checks if the instance type can be launched in the group Availability Zones: instance types not offered
+// in any zone are skipped, instance types missing in some zones are reported and skipped only if all zones are required;
+// nil offerings accept any instance type
+func (o *zoneOfferings) available(instanceType string) bool {
+	if o == nil {
+		return true
+	}
+	zones := o.offered[instanceType]
+	if len(zones) == 0 {
+		log.Printf("instance type %v is not offered in Availability Zones %v, skipping", instanceType, strings.Join(o.zones, ", "))
+		return false
+	}
+	if len(zones) < len(o.zones) {
+		missing := missingZones(o.zones, zones)
+		if o.requireAll {
+			log.Printf("instance type %v is not offered in Availability Zones %v, skipping", instanceType, strings.Join(missing, ", "))
+			return false
+		}
+		log.Printf("instance type %v is not offered in Availability Zones %v", instanceType, strings.Join(missing, ", "))
+	}
+	return true
+}
+
+// missingZones returns zones, that are not in the offered zones
+func missingZones(zones, offered []string) []string {
+	var missing []string
+	for _, z := range zones {
+		found := false
+		for _, o := range offered {
+			if z == o {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, z)
+		}
+	}
+	return missing
+}
+
+// groupZones returns Availability Zones of the group subnets (VPCZoneIdentifier) or the group Availability Zones without subnets
+func (s *asgUpdaterService) groupZones(ctx context.Context, group *autoscaling.Group) ([]string, error) {
+	var subnets []string
+	for _, id := range strings.Split(aws.StringValue(group.VPCZoneIdentifier), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			subnets = append(subnets, id)
+		}
+	}
+	if len(subnets) == 0 {
+		return aws.StringValueSlice(group.AvailabilityZones), nil
+	}
+	return s.ofsvc.SubnetZones(ctx, subnets)
+}
+
+// zoneOfferings describes instance types offered in the group Availability Zones; it returns nil (no filtering)
+// without zones or if offerings cannot be described
+func (s *asgUpdaterService) zoneOfferings(ctx context.Context, group *autoscaling.Group, zones []string, config Config) *zoneOfferings {
+	if len(zones) == 0 {
+		return nil
+	}
+	offered, err := s.ofsvc.InstanceTypeZones(ctx, zones)
+	if err != nil {
+		log.Printf("failed to get instance type offerings for the autoscaling group %v, skipping instance type offerings check: %v", aws.StringValue(group.AutoScalingGroupARN), err)
+		return nil
+	}
+	return &zoneOfferings{zones: zones, offered: offered, requireAll: config.RequireAllZones}
+}
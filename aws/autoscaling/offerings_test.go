@@ -0,0 +1,128 @@
+package autoscaling
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	ec2mocks "github.com/doitintl/spotzero/mocks/ec2"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_zoneOfferings_available(t *testing.T) {
+	offered := map[string][]string{
+		"m5.large":  {"us-east-1a", "us-east-1b"},
+		"m5n.large": {"us-east-1a"},
+	}
+	tests := []struct {
+		name         string
+		offerings    *zoneOfferings
+		instanceType string
+		want         bool
+	}{
+		{name: "no offerings", instanceType: "m5.large", want: true},
+		{name: "offered in all zones", offerings: &zoneOfferings{zones: []string{"us-east-1a", "us-east-1b"}, offered: offered}, instanceType: "m5.large", want: true},
+		{name: "offered in some zones", offerings: &zoneOfferings{zones: []string{"us-east-1a", "us-east-1b"}, offered: offered}, instanceType: "m5n.large", want: true},
+		{name: "offered in some zones, all required", offerings: &zoneOfferings{zones: []string{"us-east-1a", "us-east-1b"}, offered: offered, requireAll: true}, instanceType: "m5n.large"},
+		{name: "not offered", offerings: &zoneOfferings{zones: []string{"us-east-1a", "us-east-1b"}, offered: offered}, instanceType: "m5d.large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.offerings.available(tt.instanceType); got != tt.want {
+				t.Errorf("available() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_asgUpdaterService_groupZones(t *testing.T) {
+	tests := []struct {
+		name        string
+		group       *autoscaling.Group
+		subnetZones []string
+		zonesErr    error
+		want        []string
+		wantErr     bool
+	}{
+		{
+			name:        "subnet zones",
+			group:       &autoscaling.Group{VPCZoneIdentifier: aws.String("subnet-1, subnet-2"), AvailabilityZones: aws.StringSlice([]string{"us-east-1c"})},
+			subnetZones: []string{"us-east-1a", "us-east-1b"},
+			want:        []string{"us-east-1a", "us-east-1b"},
+		},
+		{
+			name:  "group zones without subnets",
+			group: &autoscaling.Group{VPCZoneIdentifier: aws.String(""), AvailabilityZones: aws.StringSlice([]string{"us-east-1c"})},
+			want:  []string{"us-east-1c"},
+		},
+		{
+			name:     "fail to get subnet zones",
+			group:    &autoscaling.Group{VPCZoneIdentifier: aws.String("subnet-1")},
+			zonesErr: errors.New("access denied"),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mockOfSvc := new(ec2mocks.OfferingDescriber)
+			if aws.StringValue(tt.group.VPCZoneIdentifier) != "" {
+				mockOfSvc.On("SubnetZones", ctx, mock.Anything).Return(tt.subnetZones, tt.zonesErr).Once()
+			}
+			s := &asgUpdaterService{ofsvc: mockOfSvc}
+			got, err := s.groupZones(ctx, tt.group)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("groupZones() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupZones() = %v, want %v", got, tt.want)
+			}
+			mockOfSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_asgUpdaterService_zoneOfferings(t *testing.T) {
+	offered := map[string][]string{"m5.large": {"us-east-1a"}}
+	tests := []struct {
+		name      string
+		zones     []string
+		config    Config
+		offersErr error
+		want      *zoneOfferings
+	}{
+		{
+			name:   "offerings in zones",
+			zones:  []string{"us-east-1a", "us-east-1b"},
+			config: Config{RequireAllZones: true},
+			want:   &zoneOfferings{zones: []string{"us-east-1a", "us-east-1b"}, offered: offered, requireAll: true},
+		},
+		{
+			name: "no zones",
+		},
+		{
+			name:      "fail to get offerings",
+			zones:     []string{"us-east-1a"},
+			offersErr: errors.New("access denied"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mockOfSvc := new(ec2mocks.OfferingDescriber)
+			if len(tt.zones) > 0 {
+				mockOfSvc.On("InstanceTypeZones", ctx, tt.zones).Return(offered, tt.offersErr).Once()
+			}
+			s := &asgUpdaterService{ofsvc: mockOfSvc}
+			group := &autoscaling.Group{AutoScalingGroupARN: aws.String("test-asg")}
+			if got := s.zoneOfferings(ctx, group, tt.zones, tt.config); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("zoneOfferings() = %v, want %v", got, tt.want)
+			}
+			mockOfSvc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package autoscaling
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+// A configOverride applies a spotzero tag value to the update configuration
+type configOverride func(value string, config *Config) error
+
+// configOverrides spotzero tags, that override the update configuration for the group
+var configOverrides = map[string]configOverride{
+	"spotzero:ondemand-base-capacity": func(value string, config *Config) error {
+		return parseInt64Override(value, 0, -1, &config.OnDemandBaseCapacity)
+	},
+	"spotzero:ondemand-percentage": func(value string, config *Config) error {
+		return parseInt64Override(value, 0, 100, &config.OnDemandPercentageAboveBaseCapacity)
+	},
+	"spotzero:ignore-family": func(value string, config *Config) error {
+		return parseBoolOverride(value, &config.SimilarityConfig.IgnoreFamily)
+	},
+	"spotzero:ignore-generation": func(value string, config *Config) error {
+		return parseBoolOverride(value, &config.SimilarityConfig.IgnoreGeneration)
+	},
+	"spotzero:mfu": func(value string, config *Config) error {
+		return parseIntOverride(value, 1, &config.SimilarityConfig.MultiplyFactorUpper)
+	},
+	"spotzero:mfl": func(value string, config *Config) error {
+		return parseIntOverride(value, 1, &config.SimilarityConfig.MultiplyFactorLower)
+	},
+}
+
+// groupConfig returns the update configuration with overrides from the matching policy rule and the group spotzero tags,
+// and the list of applied overrides. It returns an error listing all invalid override tags or invalid resulting configuration.
+func (c Config) groupConfig(group *autoscaling.Group) (Config, []string, error) {
+	config := c
+	var applied, invalid []string
+	if rule := c.Policy.Match(group); rule != nil {
+		rule.Config.apply(&config)
+		applied = append(applied, fmt.Sprintf("config rule %q", rule.Name))
+	}
+	var tags []string
+	for _, t := range group.Tags {
+		key := aws.StringValue(t.Key)
+		override, ok := configOverrides[key]
+		if !ok {
+			continue
+		}
+		value := aws.StringValue(t.Value)
+		if err := override(value, &config); err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s=%q: %v", key, value, err))
+			continue
+		}
+		tags = append(tags, fmt.Sprintf("%s=%s", key, value))
+	}
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return c, nil, fmt.Errorf("invalid spotzero tags for the autoscaling group %v: %s",
+			aws.StringValue(group.AutoScalingGroupName), strings.Join(invalid, "; "))
+	}
+	if err := config.Validate(); err != nil {
+		return c, nil, fmt.Errorf("invalid configuration for the autoscaling group %v: %v", aws.StringValue(group.AutoScalingGroupName), err)
+	}
+	sort.Strings(tags)
+	return config, append(applied, tags...), nil
+}
+
+// parseInt64Override parses an integer in [min, max] range; negative max means no upper limit
+func parseInt64Override(value string, min, max int64, dst *int64) error {
+	v, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return errors.New("expected integer")
+	}
+	if v < min || (max >= 0 && v > max) {
+		if max < 0 {
+			return fmt.Errorf("expected integer >= %d", min)
+		}
+		return fmt.Errorf("expected integer between %d and %d", min, max)
+	}
+	*dst = v
+	return nil
+}
+
+func parseIntOverride(value string, min int, dst *int) error {
+	var v int64
+	if err := parseInt64Override(value, int64(min), -1, &v); err != nil {
+		return err
+	}
+	*dst = int(v)
+	return nil
+}
+
+func parseBoolOverride(value string, dst *bool) error {
+	v, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return errors.New("expected true or false")
+	}
+	*dst = v
+	return nil
+}
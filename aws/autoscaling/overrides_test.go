@@ -0,0 +1,69 @@
+package autoscaling
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/doitintl/spotzero/aws/ec2"
+)
+
+func TestConfig_groupConfig(t *testing.T) {
+	base := Config{
+		SimilarityConfig:                    ec2.Config{MultiplyFactorUpper: 2, MultiplyFactorLower: 2},
+		OnDemandBaseCapacity:                0,
+		OnDemandPercentageAboveBaseCapacity: 0,
+	}
+	tests := []struct {
+		name        string
+		tags        []*autoscaling.TagDescription
+		want        Config
+		wantApplied []string
+		wantErr     bool
+	}{
+		{
+			name: "no override tags",
+			tags: testTags("env", "dev", spotzeroUpdatedTag, "false"),
+			want: base,
+		},
+		{
+			name: "override tags",
+			tags: testTags("spotzero:ondemand-base-capacity", "4", "spotzero:ondemand-percentage", "25", "spotzero:ignore-family", "true", "spotzero:mfu", "4"),
+			want: Config{
+				SimilarityConfig:                    ec2.Config{IgnoreFamily: true, MultiplyFactorUpper: 4, MultiplyFactorLower: 2},
+				OnDemandBaseCapacity:                4,
+				OnDemandPercentageAboveBaseCapacity: 25,
+			},
+			wantApplied: []string{"spotzero:ignore-family=true", "spotzero:mfu=4", "spotzero:ondemand-base-capacity=4", "spotzero:ondemand-percentage=25"},
+		},
+		{
+			name:    "fail: invalid percentage",
+			tags:    testTags("spotzero:ondemand-percentage", "150"),
+			want:    base,
+			wantErr: true,
+		},
+		{
+			name:    "fail: invalid values",
+			tags:    testTags("spotzero:ignore-family", "maybe", "spotzero:mfl", "0", "spotzero:ondemand-base-capacity", "four"),
+			want:    base,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := &autoscaling.Group{AutoScalingGroupName: aws.String("test-asg"), Tags: tt.tags}
+			got, applied, err := base.groupConfig(group)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("groupConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupConfig() got = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(applied, tt.wantApplied) {
+				t.Errorf("groupConfig() applied = %v, want %v", applied, tt.wantApplied)
+			}
+		})
+	}
+}
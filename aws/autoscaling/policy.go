@@ -0,0 +1,246 @@
+package autoscaling
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"gopkg.in/yaml.v3"
+)
+
+// A Policy is an ordered list of rules; the first rule matching an EC2 Auto Scaling group is applied to the group
+type Policy struct {
+	Rules []*PolicyRule `yaml:"rules"`
+}
+
+// A PolicyRule selects EC2 Auto Scaling groups and configures their update
+type PolicyRule struct {
+	// Name rule name reported for matching groups; defaults to "rule N"
+	Name string `yaml:"name"`
+	// Match group selector; an empty selector matches all groups
+	Match PolicyMatch `yaml:"match"`
+	// Exclude skip matching groups (excluded-by-policy)
+	Exclude bool `yaml:"exclude"`
+	// Config update configuration for matching groups; overrides command line flags
+	Config RuleConfig `yaml:"config"`
+}
+
+// A PolicyMatch selects EC2 Auto Scaling groups; all specified conditions must match
+type PolicyMatch struct {
+	// Tags tag selector expression (see ParseSelector)
+	Tags string `yaml:"tags"`
+	// Names group names, ARNs, globs or regular expressions (see ParseNameMatcher)
+	Names []string `yaml:"names"`
+	// Regions AWS Regions; values can contain `*` wildcard
+	Regions []string `yaml:"regions"`
+	// Accounts AWS account IDs; values can contain `*` wildcard
+	Accounts []string `yaml:"accounts"`
+
+	selector Selector
+	names    NameMatcher
+}
+
+// A RuleConfig overrides the update Config; only specified settings are overridden
+type RuleConfig struct {
+	IgnoreFamily                        *bool          `yaml:"ignore-family"`
+	IgnoreGeneration                    *bool          `yaml:"ignore-generation"`
+	MultiplyFactorUpper                 *int           `yaml:"multiply-factor-upper"`
+	MultiplyFactorLower                 *int           `yaml:"multiply-factor-lower"`
+	MemoryPerVCPUTolerance              *float64       `yaml:"memory-per-vcpu-tolerance"`
+	MinMemory                           *float64       `yaml:"min-memory"`
+	MaxMemory                           *float64       `yaml:"max-memory"`
+	IgnoreInstanceStore                 *bool          `yaml:"ignore-instance-store"`
+	MinInstanceStore                    *float64       `yaml:"min-instance-store"`
+	IgnoreNetworkPerformance            *bool          `yaml:"ignore-network-performance"`
+	MinNetworkPerformance               *float64       `yaml:"min-network-performance"`
+	IgnoreEBSBandwidth                  *bool          `yaml:"ignore-ebs-bandwidth"`
+	MinEBSBandwidth                     *float64       `yaml:"min-ebs-bandwidth"`
+	OnDemandBaseCapacity                *int64         `yaml:"ondemand-base-capacity"`
+	OnDemandPercentageAboveBaseCapacity *int64         `yaml:"ondemand-percentage-above-base-capacity"`
+	SpotAllocationStrategy              *string        `yaml:"spot-allocation-strategy"`
+	SpotInstancePools                   *int64         `yaml:"spot-instance-pools"`
+	OnDemandAllocationStrategy          *string        `yaml:"ondemand-allocation-strategy"`
+	InstanceRequirements                *bool          `yaml:"instance-requirements"`
+	Weighting                           *string        `yaml:"weighting"`
+	ArmImageID                          *string        `yaml:"arm-image-id"`
+	ArmLaunchTemplate                   *string        `yaml:"arm-launch-template"`
+	SpotMaxPrice                        *string        `yaml:"spot-max-price"`
+	CapacityRebalance                   *bool          `yaml:"capacity-rebalance"`
+	MaxInstanceLifetime                 *int64         `yaml:"max-instance-lifetime"`
+	RequireAllZones                     *bool          `yaml:"require-all-zones"`
+	PriceRanking                        *string        `yaml:"price-ranking"`
+	MaxPriceIncrease                    *float64       `yaml:"max-price-increase"`
+	MigrateLaunchConfigurations         *bool          `yaml:"migrate-launch-configurations"`
+	NoRefresh                           *bool          `yaml:"no-refresh"`
+	MinHealthyPercentage                *int64         `yaml:"min-healthy-percentage"`
+	InstanceWarmup                      *int64         `yaml:"instance-warmup"`
+	CheckpointPercentages               []int64        `yaml:"checkpoint-percentages"`
+	CheckpointDelay                     *int64         `yaml:"checkpoint-delay"`
+	SkipMatching                        *bool          `yaml:"skip-matching"`
+	Wait                                *bool          `yaml:"wait"`
+	WaitTimeout                         *time.Duration `yaml:"wait-timeout"`
+}
+
+// LoadPolicy reads the policy from YAML or JSON file.
+// It returns an error for unknown settings, invalid tag selectors or name patterns.
+func LoadPolicy(path string) (*Policy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening config file: %v", err)
+	}
+	defer f.Close()
+	return parsePolicy(f)
+}
+
+func parsePolicy(r io.Reader) (*Policy, error) {
+	var policy Policy
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	err := decoder.Decode(&policy)
+	if err == io.EOF {
+		return nil, errors.New("empty config file")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config file: %v", err)
+	}
+	for i, rule := range policy.Rules {
+		if rule == nil {
+			return nil, fmt.Errorf("empty rule %d in config file", i+1)
+		}
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if rule.Match.selector, err = ParseSelector(rule.Match.Tags); err != nil {
+			return nil, fmt.Errorf("invalid config rule %q: %v", rule.Name, err)
+		}
+		if rule.Match.names, err = ParseNameMatcher(rule.Match.Names); err != nil {
+			return nil, fmt.Errorf("invalid config rule %q: %v", rule.Name, err)
+		}
+	}
+	return &policy, nil
+}
+
+// Match returns the first rule matching the EC2 Auto Scaling group or nil
+func (p *Policy) Match(group *autoscaling.Group) *PolicyRule {
+	if p == nil {
+		return nil
+	}
+	for _, rule := range p.Rules {
+		if rule.Match.matches(group) {
+			return rule
+		}
+	}
+	return nil
+}
+
+func (m PolicyMatch) matches(group *autoscaling.Group) bool {
+	if !m.names.Empty() && !m.names.Matches(group) {
+		return false
+	}
+	if !m.selector.Matches(group.Tags) {
+		return false
+	}
+	if len(m.Regions) == 0 && len(m.Accounts) == 0 {
+		return true
+	}
+	groupArn, err := arn.Parse(aws.StringValue(group.AutoScalingGroupARN))
+	if err != nil {
+		return false
+	}
+	return matchAny(m.Regions, groupArn.Region) && matchAny(m.Accounts, groupArn.AccountID)
+}
+
+// matchAny returns true if patterns are empty or any pattern matches the value
+func matchAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if matchValue(p, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply overrides specified settings in the update configuration
+func (r RuleConfig) apply(config *Config) {
+	setBool(&config.SimilarityConfig.IgnoreFamily, r.IgnoreFamily)
+	setBool(&config.SimilarityConfig.IgnoreGeneration, r.IgnoreGeneration)
+	if r.MultiplyFactorUpper != nil {
+		config.SimilarityConfig.MultiplyFactorUpper = *r.MultiplyFactorUpper
+	}
+	if r.MultiplyFactorLower != nil {
+		config.SimilarityConfig.MultiplyFactorLower = *r.MultiplyFactorLower
+	}
+	setFloat64(&config.SimilarityConfig.MemoryPerVCPUTolerance, r.MemoryPerVCPUTolerance)
+	setFloat64(&config.SimilarityConfig.MinMemory, r.MinMemory)
+	setFloat64(&config.SimilarityConfig.MaxMemory, r.MaxMemory)
+	setBool(&config.SimilarityConfig.IgnoreInstanceStore, r.IgnoreInstanceStore)
+	setFloat64(&config.SimilarityConfig.MinInstanceStore, r.MinInstanceStore)
+	setBool(&config.SimilarityConfig.IgnoreNetworkPerformance, r.IgnoreNetworkPerformance)
+	setFloat64(&config.SimilarityConfig.MinNetworkPerformance, r.MinNetworkPerformance)
+	setBool(&config.SimilarityConfig.IgnoreEBSBandwidth, r.IgnoreEBSBandwidth)
+	setFloat64(&config.SimilarityConfig.MinEBSBandwidth, r.MinEBSBandwidth)
+	setInt64(&config.OnDemandBaseCapacity, r.OnDemandBaseCapacity)
+	setInt64(&config.OnDemandPercentageAboveBaseCapacity, r.OnDemandPercentageAboveBaseCapacity)
+	setString(&config.SpotAllocationStrategy, r.SpotAllocationStrategy)
+	setInt64(&config.SpotInstancePools, r.SpotInstancePools)
+	setString(&config.OnDemandAllocationStrategy, r.OnDemandAllocationStrategy)
+	setBool(&config.InstanceRequirements, r.InstanceRequirements)
+	setString(&config.Weighting, r.Weighting)
+	setString(&config.ArmImageID, r.ArmImageID)
+	setString(&config.ArmLaunchTemplate, r.ArmLaunchTemplate)
+	setString(&config.SpotMaxPrice, r.SpotMaxPrice)
+	setBool(&config.CapacityRebalance, r.CapacityRebalance)
+	setInt64(&config.MaxInstanceLifetime, r.MaxInstanceLifetime)
+	setBool(&config.RequireAllZones, r.RequireAllZones)
+	setString(&config.PriceRanking, r.PriceRanking)
+	setFloat64(&config.MaxPriceIncrease, r.MaxPriceIncrease)
+	setBool(&config.MigrateLaunchConfigurations, r.MigrateLaunchConfigurations)
+	setBool(&config.NoRefresh, r.NoRefresh)
+	setInt64(&config.MinHealthyPercentage, r.MinHealthyPercentage)
+	setInt64(&config.InstanceWarmup, r.InstanceWarmup)
+	if r.CheckpointPercentages != nil {
+		config.CheckpointPercentages = r.CheckpointPercentages
+	}
+	setInt64(&config.CheckpointDelay, r.CheckpointDelay)
+	setBool(&config.SkipMatching, r.SkipMatching)
+	setBool(&config.WaitForRefresh, r.Wait)
+	setDuration(&config.RefreshTimeout, r.WaitTimeout)
+}
+
+func setBool(dst, value *bool) {
+	if value != nil {
+		*dst = *value
+	}
+}
+
+func setString(dst, value *string) {
+	if value != nil {
+		*dst = *value
+	}
+}
+
+func setInt64(dst, value *int64) {
+	if value != nil {
+		*dst = *value
+	}
+}
+
+func setFloat64(dst, value *float64) {
+	if value != nil {
+		*dst = *value
+	}
+}
+
+func setDuration(dst, value *time.Duration) {
+	if value != nil {
+		*dst = *value
+	}
+}
@@ -0,0 +1,135 @@
+package autoscaling
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/doitintl/spotzero/aws/ec2"
+)
+
+const testPolicy = `
+rules:
+  - name: keep-prod-on-demand
+    match:
+      tags: env=prod
+      accounts: ["123456789012"]
+    exclude: true
+  - name: batch
+    match:
+      names: ["batch-*"]
+      regions: ["us-*"]
+    config:
+      ondemand-percentage-above-base-capacity: 10
+      ignore-family: true
+      checkpoint-percentages: [50, 100]
+      checkpoint-delay: 300
+      wait: true
+      wait-timeout: 90m
+  - match:
+      tags: team
+    config:
+      ondemand-base-capacity: 2
+`
+
+func testGroup(name, arn string, tags ...string) *autoscaling.Group {
+	return &autoscaling.Group{
+		AutoScalingGroupName: aws.String(name),
+		AutoScalingGroupARN:  aws.String(arn),
+		Tags:                 testTags(tags...),
+	}
+}
+
+func TestPolicy_Match(t *testing.T) {
+	policy, err := parsePolicy(strings.NewReader(testPolicy))
+	if err != nil {
+		t.Fatalf("parsePolicy() error = %v", err)
+	}
+	usArn := "arn:aws:autoscaling:us-east-1:123456789012:autoScalingGroup:uuid:autoScalingGroupName/"
+	euArn := "arn:aws:autoscaling:eu-west-1:210987654321:autoScalingGroup:uuid:autoScalingGroupName/"
+	tests := []struct {
+		name  string
+		group *autoscaling.Group
+		want  string
+	}{
+		{name: "match tags and account", group: testGroup("web", usArn+"web", "env", "prod"), want: "keep-prod-on-demand"},
+		{name: "account should not match", group: testGroup("web", euArn+"web", "env", "prod")},
+		{name: "match names and region", group: testGroup("batch-1", usArn+"batch-1", "env", "prod", "team", "data"), want: "keep-prod-on-demand"},
+		{name: "first match wins", group: testGroup("batch-1", usArn+"batch-1", "team", "data"), want: "batch"},
+		{name: "default rule name", group: testGroup("batch-1", euArn+"batch-1", "team", "data"), want: "rule 3"},
+		{name: "no match", group: testGroup("api", euArn+"api")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if rule := policy.Match(tt.group); rule != nil {
+				got = rule.Name
+			}
+			if got != tt.want {
+				t.Errorf("Match() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parsePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{name: "yaml", config: testPolicy},
+		{name: "json", config: `{"rules": [{"match": {"names": ["web-*"]}, "config": {"skip-matching": true}}]}`},
+		{name: "memory constraints", config: "rules:\n  - config:\n      memory-per-vcpu-tolerance: 0.25\n      min-memory: 4\n      max-memory: 64\n"},
+		{name: "instance store and performance", config: "rules:\n  - config:\n      ignore-instance-store: true\n      min-instance-store: 100\n      ignore-network-performance: true\n      ignore-ebs-bandwidth: true\n"},
+		{name: "minimum network performance and EBS bandwidth", config: "rules:\n  - config:\n      min-network-performance: 10\n      min-ebs-bandwidth: 4750\n"},
+		{name: "require all zones", config: "rules:\n  - config:\n      require-all-zones: true\n"},
+		{name: "price ranking", config: "rules:\n  - config:\n      price-ranking: spot\n      max-price-increase: 20\n"},
+		{name: "spot max price", config: "rules:\n  - config:\n      spot-max-price: 0.05\n  - config:\n      spot-max-price: 80%\n"},
+		{name: "wait for instance refresh", config: "rules:\n  - config:\n      wait: true\n      wait-timeout: 90m\n"},
+		{name: "fail: invalid wait timeout", config: "rules:\n  - config:\n      wait-timeout: 90\n", wantErr: true},
+		{name: "fail: empty file", config: "", wantErr: true},
+		{name: "fail: unknown setting", config: "rules:\n  - config:\n      ondemand-base: 2\n", wantErr: true},
+		{name: "fail: invalid selector", config: "rules:\n  - match:\n      tags: 'env in (dev'\n", wantErr: true},
+		{name: "fail: invalid name pattern", config: "rules:\n  - match:\n      names: ['/web-[/']\n", wantErr: true},
+		{name: "fail: invalid value type", config: "rules:\n  - config:\n      no-refresh: maybe\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parsePolicy(strings.NewReader(tt.config)); (err != nil) != tt.wantErr {
+				t.Errorf("parsePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_groupConfig_policy(t *testing.T) {
+	policy, err := parsePolicy(strings.NewReader(testPolicy))
+	if err != nil {
+		t.Fatalf("parsePolicy() error = %v", err)
+	}
+	base := Config{SimilarityConfig: ec2.Config{MultiplyFactorUpper: 2, MultiplyFactorLower: 2}, MinHealthyPercentage: 90, Policy: policy}
+	group := testGroup("batch-1", "arn:aws:autoscaling:us-east-1:123456789012:autoScalingGroup:uuid:autoScalingGroupName/batch-1",
+		"spotzero:ondemand-percentage", "20")
+	got, applied, err := base.groupConfig(group)
+	if err != nil {
+		t.Fatalf("groupConfig() error = %v", err)
+	}
+	want := base
+	want.SimilarityConfig.IgnoreFamily = true
+	want.CheckpointPercentages = []int64{50, 100}
+	want.CheckpointDelay = 300
+	want.WaitForRefresh = true
+	want.RefreshTimeout = 90 * time.Minute
+	// spotzero tag overrides the policy rule
+	want.OnDemandPercentageAboveBaseCapacity = 20
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groupConfig() got = %+v, want %+v", got, want)
+	}
+	if wantApplied := []string{`config rule "batch"`, "spotzero:ondemand-percentage=20"}; !reflect.DeepEqual(applied, wantApplied) {
+		t.Errorf("groupConfig() applied = %v, want %v", applied, wantApplied)
+	}
+}
@@ -0,0 +1,202 @@
+package autoscaling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/doitintl/spotzero/aws/ec2"
+)
+
+const (
+	// PriceRankingOnDemand rank similar instance types by the on-demand price per capacity unit
+	PriceRankingOnDemand = "ondemand"
+	// PriceRankingSpot rank similar instance types by the current Spot price per capacity unit
+	PriceRankingSpot = "spot"
+)
+
+// parseSpotMaxPrice parses an absolute price (0.05) or a percentage of the on-demand price (80%)
+func parseSpotMaxPrice(value string) (price float64, percentage bool, err error) {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, "%") {
+		percentage = true
+		value = strings.TrimSpace(strings.TrimSuffix(value, "%"))
+	}
+	price, err = strconv.ParseFloat(value, 64)
+	if err != nil || price <= 0 {
+		return 0, false, errors.New("expected positive price or percentage of the on-demand price")
+	}
+	if percentage && price > 100 {
+		return 0, false, errors.New("expected percentage of the on-demand price between 0 and 100")
+	}
+	return price, percentage, nil
+}
+
+// spotMaxPrice returns the maximum Spot price per unit hour for the group overrides.
+// A percentage is applied to the on-demand price of the original instance type divided by its capacity units:
+// vCPUs or memory (MiB) with vcpu or memory desired capacity type, otherwise its weight (1 if not weighted).
+func spotMaxPrice(group *autoscaling.Group, instanceType string, capacityType *string, overrides []*autoscaling.LaunchTemplateOverrides, value string) (string, error) {
+	price, percentage, err := parseSpotMaxPrice(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid spot max price %q: %v", value, err)
+	}
+	if !percentage {
+		return strconv.FormatFloat(price, 'f', -1, 64), nil
+	}
+	groupArn, err := arn.Parse(aws.StringValue(group.AutoScalingGroupARN))
+	if err != nil {
+		return "", fmt.Errorf("failed to get autoscaling group region: %v", err)
+	}
+	onDemandPrice, err := ec2.GetOnDemandPrice(instanceType, groupArn.Region)
+	if err != nil {
+		return "", err
+	}
+	units := capacityUnits(capacityType, overrides, instanceType)
+	return formatPrice(onDemandPrice * price / 100 / units), nil
+}
+
+// formatPrice formats the price with 5 significant digits in decimal notation (price per MiB is below $0.00001)
+func formatPrice(price float64) string {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(price, 'g', 5, 64), 64)
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+// validatePrice checks price ranking and maximum price increase
+func (c Config) validatePrice() error {
+	switch c.PriceRanking {
+	case "", PriceRankingOnDemand, PriceRankingSpot:
+	default:
+		return fmt.Errorf("invalid price ranking %q: expected %s or %s", c.PriceRanking, PriceRankingOnDemand, PriceRankingSpot)
+	}
+	if c.MaxPriceIncrease < 0 {
+		return fmt.Errorf("invalid max price increase %v: expected non-negative percentage", c.MaxPriceIncrease)
+	}
+	if c.InstanceRequirements && (c.PriceRanking != "" || c.MaxPriceIncrease > 0) {
+		return errors.New("price ranking and max price increase are not supported with instance requirements")
+	}
+	return nil
+}
+
+// priceSource returns prices to rank and filter instance types by: on-demand prices by default, if only the maximum
+// price increase is set; empty if prices are not compared
+func (c Config) priceSource() string {
+	if c.PriceRanking == "" && c.MaxPriceIncrease > 0 {
+		return PriceRankingOnDemand
+	}
+	return c.PriceRanking
+}
+
+// instancePrices returns hourly prices of instance types: on-demand prices in the group region or Spot prices
+// in the group Availability Zones (averaged); nil if prices are not compared
+func (s *asgUpdaterService) instancePrices(ctx context.Context, group *autoscaling.Group, zones []string, config Config) (map[string]float64, error) {
+	switch config.priceSource() {
+	case PriceRankingOnDemand:
+		groupArn, err := arn.Parse(aws.StringValue(group.AutoScalingGroupARN))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get autoscaling group region: %v", err)
+		}
+		return ec2.GetOnDemandPrices(groupArn.Region), nil
+	case PriceRankingSpot:
+		return s.spsvc.GetSpotPrices(ctx, zones)
+	}
+	return nil, nil
+}
+
+// unitPrice returns the hourly price per capacity unit of the override (unweighted override is a single unit)
+// and false, if the instance type price is unknown
+func unitPrice(o *autoscaling.LaunchTemplateOverrides, prices map[string]float64) (float64, bool) {
+	price := prices[aws.StringValue(o.InstanceType)]
+	if price <= 0 {
+		return 0, false
+	}
+	weight := 1.0
+	if o.WeightedCapacity != nil {
+		if w, err := strconv.ParseFloat(*o.WeightedCapacity, 64); err == nil && w > 0 {
+			weight = w
+		}
+	}
+	return price / weight, true
+}
+
+// priceOverrides skips instance types priced more than MaxPriceIncrease percent per capacity unit above the original
+// instance type (or without known price) and, with price ranking, sorts the rest by price per capacity unit
+// (unknown prices last); the original instance type stays first
+func priceOverrides(overrides []*autoscaling.LaunchTemplateOverrides, original *autoscaling.LaunchTemplateOverrides, prices map[string]float64, config Config) []*autoscaling.LaunchTemplateOverrides {
+	originalPrice, ok := unitPrice(original, prices)
+	maxPrice := originalPrice * (1 + config.MaxPriceIncrease/100)
+	if config.MaxPriceIncrease > 0 && !ok {
+		log.Printf("unknown price of the original instance type %v, skipping max price increase check", aws.StringValue(original.InstanceType))
+	}
+	filtered := make([]*autoscaling.LaunchTemplateOverrides, 0, len(overrides))
+	for _, o := range overrides {
+		price, known := unitPrice(o, prices)
+		if config.MaxPriceIncrease > 0 && ok && (!known || price > maxPrice) {
+			log.Printf("instance type %v price per unit hour %v is above %v, skipping", aws.StringValue(o.InstanceType), priceValue(price, known), priceValue(maxPrice, true))
+			continue
+		}
+		filtered = append(filtered, o)
+	}
+	if config.PriceRanking == "" {
+		return filtered
+	}
+	// rank: the original instance type, known prices (ascending), unknown prices
+	rank := func(o *autoscaling.LaunchTemplateOverrides) (int, float64) {
+		if aws.StringValue(o.InstanceType) == aws.StringValue(original.InstanceType) {
+			return 0, 0
+		}
+		if price, known := unitPrice(o, prices); known {
+			return 1, price
+		}
+		return 2, 0
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		iRank, iPrice := rank(filtered[i])
+		jRank, jPrice := rank(filtered[j])
+		if iRank != jRank {
+			return iRank < jRank
+		}
+		return iPrice < jPrice
+	})
+	return filtered
+}
+
+// A UnitPrice is the on-demand hourly price per capacity unit of the proposed instance type
+type UnitPrice struct {
+	InstanceType string  `json:"instanceType"`
+	Price        float64 `json:"onDemandPrice"`
+}
+
+func (p UnitPrice) String() string {
+	return fmt.Sprintf("%s %s", p.InstanceType, priceValue(p.Price, true))
+}
+
+// UnitPrices returns on-demand prices per capacity unit hour of the proposed instance types in the group region
+// (as shown by Diff) in the overrides order; instance types with unknown price and instance requirements are skipped
+func UnitPrices(group *autoscaling.Group, input *autoscaling.UpdateAutoScalingGroupInput) []UnitPrice {
+	if input.MixedInstancesPolicy == nil || input.MixedInstancesPolicy.LaunchTemplate == nil {
+		return nil
+	}
+	prices := groupPrices(group)
+	var unitPrices []UnitPrice
+	for _, o := range input.MixedInstancesPolicy.LaunchTemplate.Overrides {
+		if price, ok := unitPrice(o, prices); ok {
+			unitPrices = append(unitPrices, UnitPrice{InstanceType: aws.StringValue(o.InstanceType), Price: price})
+		}
+	}
+	return unitPrices
+}
+
+// priceValue formats the hourly price per capacity unit
+func priceValue(price float64, known bool) string {
+	if !known {
+		return noValue
+	}
+	return fmt.Sprintf("$%.5f", price)
+}
@@ -0,0 +1,239 @@
+package autoscaling
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	ec2mocks "github.com/doitintl/spotzero/mocks/ec2"
+)
+
+func Test_spotMaxPrice(t *testing.T) {
+	// m5.large on-demand price in us-east-1: $0.096
+	arn := "arn:aws:autoscaling:us-east-1:123456789012:autoScalingGroup:uuid:autoScalingGroupName/test-asg"
+	overrides := testOverrides("m5.large", "2", "m5.xlarge", "4")
+	requirements := []*autoscaling.LaunchTemplateOverrides{{InstanceRequirements: &autoscaling.InstanceRequirements{}}}
+	tests := []struct {
+		name         string
+		group        *autoscaling.Group
+		capacityType *string
+		overrides    []*autoscaling.LaunchTemplateOverrides
+		value        string
+		want         string
+		wantErr      bool
+	}{
+		{name: "absolute price", group: testGroup("test-asg", arn), overrides: overrides, value: "0.05", want: "0.05"},
+		{name: "percentage of on-demand price per unit", group: testGroup("test-asg", arn), overrides: overrides, value: "80%",
+			want: "0.0384"},
+		{name: "fail: negative price", group: testGroup("test-asg", arn), overrides: overrides, value: "-1", wantErr: true},
+		{name: "fail: percentage above 100", group: testGroup("test-asg", arn), overrides: overrides, value: "120%", wantErr: true},
+		{name: "fail: no region", group: testGroup("test-asg", ""), overrides: overrides, value: "80%", wantErr: true},
+		{name: "percentage of on-demand price per instance", group: testGroup("test-asg", arn), value: "80%",
+			want: "0.0768"},
+		{name: "instance requirements with vcpu capacity", group: testGroup("test-asg", arn), capacityType: aws.String(capacityTypeVCPU),
+			overrides: requirements, value: "80%", want: "0.0384"},
+		{name: "instance requirements with memory capacity", group: testGroup("test-asg", arn), capacityType: aws.String(capacityTypeMemory),
+			overrides: requirements, value: "80%", want: "0.000009375"},
+		{name: "instance requirements with units capacity", group: testGroup("test-asg", arn), capacityType: aws.String(capacityTypeUnits),
+			overrides: requirements, value: "80%", want: "0.0768"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := spotMaxPrice(tt.group, "m5.large", tt.capacityType, tt.overrides, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("spotMaxPrice() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("spotMaxPrice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_unitPrice(t *testing.T) {
+	prices := map[string]float64{"m5.large": 0.096}
+	tests := []struct {
+		name     string
+		override *autoscaling.LaunchTemplateOverrides
+		want     float64
+		wantOk   bool
+	}{
+		{name: "weighted", override: testOverrides("m5.large", "2")[0], want: 0.048, wantOk: true},
+		{name: "unweighted", override: &autoscaling.LaunchTemplateOverrides{InstanceType: aws.String("m5.large")}, want: 0.096, wantOk: true},
+		{name: "unknown price", override: testOverrides("m5.xlarge", "4")[0]},
+		{name: "instance requirements", override: &autoscaling.LaunchTemplateOverrides{InstanceRequirements: &autoscaling.InstanceRequirements{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := unitPrice(tt.override, prices)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("unitPrice() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_priceOverrides(t *testing.T) {
+	// per unit: m5.large 0.048, m5a.large 0.043, m5n.large 0.0595, m6i.xlarge 0.048
+	prices := map[string]float64{"m5.large": 0.096, "m5a.large": 0.086, "m5n.large": 0.119, "m6i.xlarge": 0.192}
+	overrides := testOverrides("m5.large", "2", "m5n.large", "2", "m5d.large", "2", "m6i.xlarge", "4", "m5a.large", "2")
+	original := testOverrides("m5.large", "2")[0]
+	tests := []struct {
+		name      string
+		config    Config
+		original  *autoscaling.LaunchTemplateOverrides
+		wantTypes []string
+	}{
+		{
+			name:      "rank by price per unit",
+			config:    Config{PriceRanking: PriceRankingOnDemand},
+			original:  original,
+			wantTypes: []string{"m5.large", "m5a.large", "m6i.xlarge", "m5n.large", "m5d.large"},
+		},
+		{
+			name:      "skip pricier instance types",
+			config:    Config{MaxPriceIncrease: 10},
+			original:  original,
+			wantTypes: []string{"m5.large", "m6i.xlarge", "m5a.large"},
+		},
+		{
+			name:      "rank and skip pricier instance types",
+			config:    Config{PriceRanking: PriceRankingSpot, MaxPriceIncrease: 25},
+			original:  original,
+			wantTypes: []string{"m5.large", "m5a.large", "m6i.xlarge", "m5n.large"},
+		},
+		{
+			name:      "unknown original price",
+			config:    Config{MaxPriceIncrease: 10},
+			original:  testOverrides("m4.large", "2")[0],
+			wantTypes: []string{"m5.large", "m5n.large", "m5d.large", "m6i.xlarge", "m5a.large"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := append([]*autoscaling.LaunchTemplateOverrides(nil), overrides...)
+			got := priceOverrides(input, tt.original, prices, tt.config)
+			var gotTypes []string
+			for _, o := range got {
+				gotTypes = append(gotTypes, aws.StringValue(o.InstanceType))
+			}
+			if !reflect.DeepEqual(gotTypes, tt.wantTypes) {
+				t.Errorf("priceOverrides() = %v, want %v", gotTypes, tt.wantTypes)
+			}
+		})
+	}
+}
+
+func TestConfig_priceSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{name: "no prices", config: Config{}},
+		{name: "max price increase", config: Config{MaxPriceIncrease: 10}, want: PriceRankingOnDemand},
+		{name: "spot price ranking", config: Config{PriceRanking: PriceRankingSpot, MaxPriceIncrease: 10}, want: PriceRankingSpot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.priceSource(); got != tt.want {
+				t.Errorf("priceSource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_asgUpdaterService_instancePrices(t *testing.T) {
+	arn := "arn:aws:autoscaling:us-east-1:123456789012:autoScalingGroup:uuid:autoScalingGroupName/test-asg"
+	zones := []string{"us-east-1a", "us-east-1b"}
+	spotPrices := map[string]float64{"m5.large": 0.035, "m5a.large": 0.032}
+	tests := []struct {
+		name      string
+		group     *autoscaling.Group
+		config    Config
+		spotErr   error
+		wantSpot  bool
+		wantPrice float64
+		wantErr   bool
+	}{
+		{name: "no prices", group: testGroup("test-asg", arn)},
+		{name: "on-demand prices", group: testGroup("test-asg", arn), config: Config{MaxPriceIncrease: 10}, wantPrice: 0.096},
+		{name: "fail: on-demand prices without region", group: testGroup("test-asg", ""), config: Config{MaxPriceIncrease: 10},
+			wantErr: true},
+		{name: "spot prices", group: testGroup("test-asg", arn), config: Config{PriceRanking: PriceRankingSpot}, wantSpot: true,
+			wantPrice: 0.035},
+		{name: "fail: spot prices", group: testGroup("test-asg", arn), config: Config{PriceRanking: PriceRankingSpot},
+			spotErr: errors.New("access denied"), wantSpot: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mockSpSvc := new(ec2mocks.SpotPriceDescriber)
+			if tt.wantSpot {
+				prices := spotPrices
+				if tt.spotErr != nil {
+					prices = nil
+				}
+				mockSpSvc.On("GetSpotPrices", ctx, zones).Return(prices, tt.spotErr).Once()
+			}
+			s := &asgUpdaterService{spsvc: mockSpSvc}
+			got, err := s.instancePrices(ctx, tt.group, zones, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("instancePrices() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got["m5.large"] != tt.wantPrice {
+				t.Errorf("instancePrices() m5.large = %v, want %v", got["m5.large"], tt.wantPrice)
+			}
+			mockSpSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUnitPrices(t *testing.T) {
+	arn := "arn:aws:autoscaling:us-east-1:123456789012:autoScalingGroup:uuid:autoScalingGroupName/test-asg"
+	input := func(overrides []*autoscaling.LaunchTemplateOverrides) *autoscaling.UpdateAutoScalingGroupInput {
+		return &autoscaling.UpdateAutoScalingGroupInput{
+			MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{LaunchTemplate: &autoscaling.LaunchTemplate{Overrides: overrides}},
+		}
+	}
+	tests := []struct {
+		name  string
+		group *autoscaling.Group
+		input *autoscaling.UpdateAutoScalingGroupInput
+		want  []UnitPrice
+	}{
+		{
+			name:  "weighted instance types in overrides order",
+			group: testGroup("test-asg", arn),
+			input: input(testOverrides("m5.xlarge", "4", "x0.tiny", "1", "m5.large", "2")),
+			want:  []UnitPrice{{InstanceType: "m5.xlarge", Price: 0.048}, {InstanceType: "m5.large", Price: 0.048}},
+		},
+		{
+			name:  "instance requirements",
+			group: testGroup("test-asg", arn),
+			input: input([]*autoscaling.LaunchTemplateOverrides{{InstanceRequirements: &autoscaling.InstanceRequirements{}}}),
+		},
+		{
+			name:  "unknown region",
+			group: testGroup("test-asg", ""),
+			input: input(testOverrides("m5.large", "2")),
+		},
+		{
+			name:  "no mixed instances policy",
+			group: testGroup("test-asg", arn),
+			input: &autoscaling.UpdateAutoScalingGroupInput{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnitPrices(tt.group, tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnitPrices() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package autoscaling
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+const (
+	// interval between DescribeInstanceRefreshes requests
+	refreshPollInterval = 30 * time.Second
+	// default instance refresh wait timeout
+	defaultRefreshTimeout = time.Hour
+	// maximum instance refresh wait timeout in AWS Lambda: the function runs up to 15 minutes
+	maxLambdaRefreshTimeout = 14 * time.Minute
+)
+
+// waitForInstanceRefresh polls the instance refresh status until it completes, fails or the timeout (1 hour if not set) expires.
+// The instance refresh is cancelled, if it does not complete before the timeout or the context is cancelled.
+// It returns an error if the instance refresh fails or is cancelled.
+func (s *asgUpdaterService) waitForInstanceRefresh(ctx context.Context, group *autoscaling.Group, refreshID *string, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = defaultRefreshTimeout
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		refresh, err := s.describeInstanceRefresh(waitCtx, group, refreshID)
+		if err != nil {
+			if waitCtx.Err() != nil {
+				return s.cancelInstanceRefresh(group, waitCtx.Err())
+			}
+			return err
+		}
+		switch status := aws.StringValue(refresh.Status); status {
+		case autoscaling.InstanceRefreshStatusSuccessful:
+			log.Printf("instance refresh %v for the autoscaling group %v completed", *refreshID, *group.AutoScalingGroupARN)
+			return nil
+		case autoscaling.InstanceRefreshStatusPending, autoscaling.InstanceRefreshStatusInProgress:
+			log.Printf("instance refresh %v for the autoscaling group %v: %v, %d%% complete, %d instances to update",
+				*refreshID, *group.AutoScalingGroupARN, status, aws.Int64Value(refresh.PercentageComplete), aws.Int64Value(refresh.InstancesToUpdate))
+		default:
+			return fmt.Errorf("instance refresh %v for the autoscaling group %v: %v: %v",
+				*refreshID, *group.AutoScalingGroupARN, status, aws.StringValue(refresh.StatusReason))
+		}
+		select {
+		case <-waitCtx.Done():
+			return s.cancelInstanceRefresh(group, waitCtx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *asgUpdaterService) describeInstanceRefresh(ctx context.Context, group *autoscaling.Group, refreshID *string) (*autoscaling.InstanceRefresh, error) {
+	output, err := s.asgsvc.DescribeInstanceRefreshesWithContext(ctx, &autoscaling.DescribeInstanceRefreshesInput{
+		AutoScalingGroupName: group.AutoScalingGroupName,
+		InstanceRefreshIds:   []*string{refreshID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error describing instance refresh for the autoscaling group: %v", err)
+	}
+	if len(output.InstanceRefreshes) != 1 {
+		return nil, fmt.Errorf("expected to get a single instance refresh %v", *refreshID)
+	}
+	return output.InstanceRefreshes[0], nil
+}
+
+// cancelInstanceRefresh cancels the instance refresh, that did not complete in time; it returns an error with the cause
+func (s *asgUpdaterService) cancelInstanceRefresh(group *autoscaling.Group, cause error) error {
+	log.Printf("cancelling instance refresh for the autoscaling group %v: %v", *group.AutoScalingGroupARN, cause)
+	// the wait context is already done
+	_, err := s.asgsvc.CancelInstanceRefreshWithContext(context.Background(), &autoscaling.CancelInstanceRefreshInput{
+		AutoScalingGroupName: group.AutoScalingGroupName,
+	})
+	if err != nil {
+		return fmt.Errorf("error cancelling instance refresh for the autoscaling group (%v): %v", cause, err)
+	}
+	return fmt.Errorf("instance refresh for the autoscaling group is cancelled: %v", cause)
+}
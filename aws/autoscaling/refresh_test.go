@@ -0,0 +1,152 @@
+package autoscaling
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/doitintl/spotzero/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func testInstanceRefreshes(status string) *autoscaling.DescribeInstanceRefreshesOutput {
+	return &autoscaling.DescribeInstanceRefreshesOutput{
+		InstanceRefreshes: []*autoscaling.InstanceRefresh{
+			{
+				InstanceRefreshId:  aws.String("test-refresh"),
+				Status:             aws.String(status),
+				StatusReason:       aws.String("test reason"),
+				PercentageComplete: aws.Int64(50),
+				InstancesToUpdate:  aws.Int64(2),
+			},
+		},
+	}
+}
+
+//nolint:funlen
+func Test_asgUpdaterService_waitForInstanceRefresh(t *testing.T) {
+	tests := []struct {
+		name        string
+		statuses    []string
+		describeErr error
+		timeout     time.Duration
+		wantCancel  bool
+		wantErr     bool
+	}{
+		{
+			name:     "wait for successful refresh",
+			statuses: []string{autoscaling.InstanceRefreshStatusPending, autoscaling.InstanceRefreshStatusInProgress, autoscaling.InstanceRefreshStatusSuccessful},
+		},
+		{
+			name:     "fail: refresh failed",
+			statuses: []string{autoscaling.InstanceRefreshStatusInProgress, autoscaling.InstanceRefreshStatusFailed},
+			wantErr:  true,
+		},
+		{
+			name:     "fail: refresh cancelled",
+			statuses: []string{autoscaling.InstanceRefreshStatusCancelled},
+			wantErr:  true,
+		},
+		{
+			name:        "fail: describe refresh",
+			describeErr: errors.New("test"),
+			wantErr:     true,
+		},
+		{
+			name:       "fail: cancel refresh on timeout",
+			statuses:   []string{autoscaling.InstanceRefreshStatusInProgress},
+			timeout:    10 * time.Millisecond,
+			wantCancel: true,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			group := &autoscaling.Group{
+				AutoScalingGroupARN:  aws.String("test-asg-arn"),
+				AutoScalingGroupName: aws.String("test-asg"),
+			}
+			mockAsgSvc := new(mocks.AwsAsgUpdater)
+			s := &asgUpdaterService{
+				asgsvc:       mockAsgSvc,
+				pollInterval: time.Millisecond,
+			}
+			describeInput := &autoscaling.DescribeInstanceRefreshesInput{
+				AutoScalingGroupName: aws.String("test-asg"),
+				InstanceRefreshIds:   aws.StringSlice([]string{"test-refresh"}),
+			}
+			if tt.describeErr != nil {
+				mockAsgSvc.On("DescribeInstanceRefreshesWithContext", mock.Anything, describeInput).Return(nil, tt.describeErr).Once()
+			}
+			for i, status := range tt.statuses {
+				call := mockAsgSvc.On("DescribeInstanceRefreshesWithContext", mock.Anything, describeInput).Return(testInstanceRefreshes(status), nil)
+				// keep returning the last status
+				if i < len(tt.statuses)-1 {
+					call.Once()
+				}
+			}
+			if tt.wantCancel {
+				mockAsgSvc.On("CancelInstanceRefreshWithContext", mock.Anything, &autoscaling.CancelInstanceRefreshInput{
+					AutoScalingGroupName: aws.String("test-asg"),
+				}).Return(&autoscaling.CancelInstanceRefreshOutput{}, nil).Once()
+			}
+			err := s.waitForInstanceRefresh(ctx, group, aws.String("test-refresh"), tt.timeout)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("waitForInstanceRefresh() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			mockAsgSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_asgUpdaterService_startInstanceRefresh(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		wantWait bool
+	}{
+		{
+			name:   "do not wait",
+			config: Config{},
+		},
+		{
+			name:     "wait with the group config",
+			config:   Config{WaitForRefresh: true, RefreshTimeout: time.Minute},
+			wantWait: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			group := &autoscaling.Group{
+				AutoScalingGroupARN:  aws.String("test-asg-arn"),
+				AutoScalingGroupName: aws.String("test-asg"),
+			}
+			mockAsgSvc := new(mocks.AwsAsgUpdater)
+			// the service config is overridden by the group config
+			s := &asgUpdaterService{
+				asgsvc:       mockAsgSvc,
+				config:       Config{WaitForRefresh: !tt.wantWait},
+				pollInterval: time.Millisecond,
+			}
+			mockAsgSvc.On("StartInstanceRefreshWithContext", ctx, &autoscaling.StartInstanceRefreshInput{
+				AutoScalingGroupName: aws.String("test-asg"),
+				Preferences:          tt.config.refreshPreferences(),
+			}).Return(&autoscaling.StartInstanceRefreshOutput{InstanceRefreshId: aws.String("test-refresh")}, nil).Once()
+			if tt.wantWait {
+				mockAsgSvc.On("DescribeInstanceRefreshesWithContext", mock.Anything, &autoscaling.DescribeInstanceRefreshesInput{
+					AutoScalingGroupName: aws.String("test-asg"),
+					InstanceRefreshIds:   aws.StringSlice([]string{"test-refresh"}),
+				}).Return(testInstanceRefreshes(autoscaling.InstanceRefreshStatusSuccessful), nil).Once()
+			}
+			if err := s.startInstanceRefresh(ctx, group, tt.config); err != nil {
+				t.Errorf("startInstanceRefresh() error = %v", err)
+			}
+			mockAsgSvc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package autoscaling
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+// legacyUpdatedTimeLayout `spotzero:updated:time` tag format (time.Time.String) used before RFC3339
+const legacyUpdatedTimeLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+// parseUpdatedTime parses the `spotzero:updated:time` tag value: RFC3339 or the legacy time.Time.String format
+func parseUpdatedTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	// drop monotonic clock reading ("m=+0.012345678")
+	if i := strings.Index(value, " m="); i >= 0 {
+		value = value[:i]
+	}
+	t, err := time.Parse(legacyUpdatedTimeLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s tag value %q", spotzeroUpdatedTimeTag, value)
+	}
+	return t, nil
+}
+
+// updatedTime returns the time, the group was updated by spotzero at
+func updatedTime(tags []*autoscaling.TagDescription) (time.Time, error) {
+	for _, tag := range tags {
+		if aws.StringValue(tag.Key) == spotzeroUpdatedTimeTag {
+			return parseUpdatedTime(aws.StringValue(tag.Value))
+		}
+	}
+	return time.Time{}, fmt.Errorf("missing %s tag", spotzeroUpdatedTimeTag)
+}
+
+// reoptimizeSkip returns why the group, already updated by spotzero, is not re-optimized at the given time;
+// empty string if the group is due for re-optimization
+func (f GroupFilter) reoptimizeSkip(group *autoscaling.Group, now time.Time) string {
+	if f.ReoptimizeAfter <= 0 {
+		return "autoscaling group is already updated by spotzero"
+	}
+	updated, err := updatedTime(group.Tags)
+	if err != nil {
+		// unknown update time: recompute and compare the policy
+		log.Printf("re-optimizing autoscaling group %v: %v", aws.StringValue(group.AutoScalingGroupARN), err)
+		return ""
+	}
+	if due := updated.Add(f.ReoptimizeAfter); now.Before(due) {
+		return fmt.Sprintf("autoscaling group is updated by spotzero at %s; re-optimized after %s", updated.Format(time.RFC3339), due.Format(time.RFC3339))
+	}
+	return ""
+}
+
+// isUpdated returns true if the group has "spotzero:updated=true" tag
+func isUpdated(group *autoscaling.Group) bool {
+	return matchesAsgTags(map[string]string{spotzeroUpdatedTag: "true"}, group.Tags)
+}
+
+// rescaleSnapshot keeps the original snapshot of the re-optimized group and records the combined capacity scale
+func (s *asgUpdaterService) rescaleSnapshot(ctx context.Context, group *autoscaling.Group, scale float64) error {
+	if scale == 1 {
+		return nil
+	}
+	snapshot, err := loadGroupSnapshot(group)
+	if err != nil {
+		return err
+	}
+	if snapshot.CapacityScale > 0 {
+		scale *= snapshot.CapacityScale
+	}
+	snapshot.CapacityScale = scale
+	if scale == 1 {
+		snapshot.CapacityScale = 0
+	}
+	tags, err := snapshot.tags(group.AutoScalingGroupName)
+	if err != nil {
+		return err
+	}
+	if err = checkTagsLimit(group, tags); err != nil {
+		return err
+	}
+	output, err := s.asgsvc.CreateOrUpdateTagsWithContext(ctx, &autoscaling.CreateOrUpdateTagsInput{Tags: tags})
+	if err != nil {
+		return fmt.Errorf("error saving snapshot for the autoscaling group: %v", err)
+	}
+	log.Printf("saved autoscaling group snapshot: %v", *output)
+	return s.deleteStaleSnapshotTags(ctx, group, tags)
+}
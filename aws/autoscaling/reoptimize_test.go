@@ -0,0 +1,239 @@
+package autoscaling
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+func Test_parseUpdatedTime(t *testing.T) {
+	want := time.Date(2022, 5, 10, 14, 30, 15, 0, time.UTC)
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "rfc3339", value: "2022-05-10T14:30:15Z"},
+		{name: "rfc3339 with offset", value: "2022-05-10T17:30:15+03:00"},
+		{name: "legacy with monotonic clock", value: "2022-05-10 14:30:15 +0000 UTC m=+0.123456789"},
+		{name: "legacy", value: "2022-05-10 16:30:15 +0200 CEST"},
+		{name: "fail: invalid", value: "last tuesday", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseUpdatedTime(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseUpdatedTime() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !got.Equal(want) {
+				t.Errorf("parseUpdatedTime() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestGroupFilter_reoptimizeSkip(t *testing.T) {
+	now := time.Date(2022, 5, 20, 0, 0, 0, 0, time.UTC)
+	week := 7 * 24 * time.Hour
+	tests := []struct {
+		name     string
+		after    time.Duration
+		tags     []*autoscaling.TagDescription
+		wantSkip bool
+	}{
+		{name: "re-optimization disabled", tags: testTags(spotzeroUpdatedTimeTag, "2022-01-01T00:00:00Z"), wantSkip: true},
+		{name: "due", after: week, tags: testTags(spotzeroUpdatedTimeTag, "2022-05-10T00:00:00Z")},
+		{name: "not due", after: week, tags: testTags(spotzeroUpdatedTimeTag, "2022-05-15T00:00:00Z"), wantSkip: true},
+		{name: "legacy not due", after: week, tags: testTags(spotzeroUpdatedTimeTag, "2022-05-15 10:00:00.123 +0000 UTC m=+0.5"), wantSkip: true},
+		{name: "missing update time", after: week},
+		{name: "invalid update time", after: week, tags: testTags(spotzeroUpdatedTimeTag, "yesterday")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := &autoscaling.Group{AutoScalingGroupARN: aws.String("test-asg-arn"), Tags: tt.tags}
+			if got := (GroupFilter{ReoptimizeAfter: tt.after}).reoptimizeSkip(group, now); (got != "") != tt.wantSkip {
+				t.Errorf("reoptimizeSkip() = %q, wantSkip %v", got, tt.wantSkip)
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func Test_changed(t *testing.T) {
+	// group, as returned by DescribeAutoScalingGroups after a spotzero update
+	group := func(spotStrategy string, onDemandPercentage int64) *autoscaling.Group {
+		return &autoscaling.Group{
+			AutoScalingGroupARN:  aws.String("test-asg-arn"),
+			AutoScalingGroupName: aws.String("test-asg"),
+			CapacityRebalance:    aws.Bool(true),
+			DesiredCapacityType:  aws.String(capacityTypeUnits),
+			MinSize:              aws.Int64(2),
+			MaxSize:              aws.Int64(8),
+			DesiredCapacity:      aws.Int64(4),
+			MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{
+				InstancesDistribution: &autoscaling.InstancesDistribution{
+					OnDemandAllocationStrategy:          aws.String(OnDemandPrioritized),
+					OnDemandBaseCapacity:                aws.Int64(0),
+					OnDemandPercentageAboveBaseCapacity: aws.Int64(onDemandPercentage),
+					SpotAllocationStrategy:              aws.String(spotStrategy),
+					SpotInstancePools:                   aws.Int64(2),
+					SpotMaxPrice:                        aws.String(""),
+				},
+				LaunchTemplate: &autoscaling.LaunchTemplate{
+					LaunchTemplateSpecification: &autoscaling.LaunchTemplateSpecification{
+						LaunchTemplateId:   aws.String("lt-1"),
+						LaunchTemplateName: aws.String("test-lt"),
+						Version:            aws.String("1"),
+					},
+					Overrides: testOverrides("m5.large", "2", "m5.xlarge", "4"),
+				},
+			},
+		}
+	}
+	// update input, as created by createUpdateInput
+	input := func(spotStrategy string, onDemandPercentage int64, overrides ...string) *autoscaling.UpdateAutoScalingGroupInput {
+		return &autoscaling.UpdateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String("test-asg"),
+			CapacityRebalance:    aws.Bool(true),
+			MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{
+				InstancesDistribution: &autoscaling.InstancesDistribution{
+					OnDemandBaseCapacity:                aws.Int64(0),
+					OnDemandPercentageAboveBaseCapacity: aws.Int64(onDemandPercentage),
+					SpotAllocationStrategy:              aws.String(spotStrategy),
+				},
+				LaunchTemplate: &autoscaling.LaunchTemplate{
+					LaunchTemplateSpecification: &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-1"), Version: aws.String("1")},
+					Overrides:                   testOverrides(overrides...),
+				},
+			},
+		}
+	}
+	modified := func(in *autoscaling.UpdateAutoScalingGroupInput, modify func(*autoscaling.UpdateAutoScalingGroupInput)) *autoscaling.UpdateAutoScalingGroupInput {
+		modify(in)
+		return in
+	}
+	tests := []struct {
+		name   string
+		group  *autoscaling.Group
+		input  *autoscaling.UpdateAutoScalingGroupInput
+		config Config
+		want   bool
+	}{
+		{
+			name:  "same policy",
+			group: group(SpotCapacityOptimized, 0),
+			input: input(SpotCapacityOptimized, 0, "m5.large", "2", "m5.xlarge", "4"),
+		},
+		{
+			name:  "reordered instance types without prioritized allocation",
+			group: group(SpotCapacityOptimized, 0),
+			input: input(SpotCapacityOptimized, 0, "m5.xlarge", "4", "m5.large", "2"),
+		},
+		{
+			name:  "reordered instance types with capacity-optimized-prioritized",
+			group: group(SpotCapacityOptimizedPrioritized, 0),
+			input: input(SpotCapacityOptimizedPrioritized, 0, "m5.xlarge", "4", "m5.large", "2"),
+			want:  true,
+		},
+		{
+			name:   "reordered instance types with spot price ranking",
+			group:  group(SpotCapacityOptimizedPrioritized, 50),
+			input:  input(SpotCapacityOptimizedPrioritized, 50, "m5.xlarge", "4", "m5.large", "2"),
+			config: Config{PriceRanking: PriceRankingSpot},
+		},
+		{
+			name:   "reordered instance types with on-demand price ranking",
+			group:  group(SpotCapacityOptimizedPrioritized, 0),
+			input:  input(SpotCapacityOptimizedPrioritized, 0, "m5.xlarge", "4", "m5.large", "2"),
+			config: Config{PriceRanking: PriceRankingOnDemand},
+			want:   true,
+		},
+		{
+			name:  "reordered instance types with prioritized on-demand instances",
+			group: group(SpotCapacityOptimized, 50),
+			input: input(SpotCapacityOptimized, 50, "m5.xlarge", "4", "m5.large", "2"),
+			want:  true,
+		},
+		{
+			name:  "new instance type",
+			group: group(SpotCapacityOptimized, 0),
+			input: input(SpotCapacityOptimized, 0, "m5.large", "2", "m5.xlarge", "4", "m6i.large", "2"),
+			want:  true,
+		},
+		{
+			name:  "changed weight",
+			group: group(SpotCapacityOptimized, 0),
+			input: input(SpotCapacityOptimized, 0, "m5.large", "1", "m5.xlarge", "4"),
+			want:  true,
+		},
+		{
+			name:  "removed instance type",
+			group: group(SpotCapacityOptimized, 0),
+			input: input(SpotCapacityOptimized, 0, "m5.large", "2"),
+			want:  true,
+		},
+		{
+			name:  "changed spot allocation strategy",
+			group: group(SpotCapacityOptimized, 0),
+			input: input(SpotPriceCapacityOptimized, 0, "m5.large", "2", "m5.xlarge", "4"),
+			want:  true,
+		},
+		{
+			name:  "changed on-demand allocation strategy",
+			group: group(SpotCapacityOptimized, 0),
+			input: modified(input(SpotCapacityOptimized, 0, "m5.large", "2", "m5.xlarge", "4"), func(in *autoscaling.UpdateAutoScalingGroupInput) {
+				in.MixedInstancesPolicy.InstancesDistribution.OnDemandAllocationStrategy = aws.String(OnDemandLowestPrice)
+			}),
+			want: true,
+		},
+		{
+			name:  "changed spot max price",
+			group: group(SpotCapacityOptimized, 0),
+			input: modified(input(SpotCapacityOptimized, 0, "m5.large", "2", "m5.xlarge", "4"), func(in *autoscaling.UpdateAutoScalingGroupInput) {
+				in.MixedInstancesPolicy.InstancesDistribution.SpotMaxPrice = aws.String("0.05")
+			}),
+			want: true,
+		},
+		{
+			name:  "changed spot instance pools with lowest price",
+			group: group(SpotLowestPrice, 0),
+			input: modified(input(SpotLowestPrice, 0, "m5.large", "2", "m5.xlarge", "4"), func(in *autoscaling.UpdateAutoScalingGroupInput) {
+				in.MixedInstancesPolicy.InstancesDistribution.SpotInstancePools = aws.Int64(4)
+			}),
+			want: true,
+		},
+		{
+			name:  "same desired capacity type",
+			group: group(SpotCapacityOptimized, 0),
+			input: modified(input(SpotCapacityOptimized, 0, "m5.large", "2", "m5.xlarge", "4"), func(in *autoscaling.UpdateAutoScalingGroupInput) {
+				in.DesiredCapacityType = aws.String(capacityTypeUnits)
+			}),
+		},
+		{
+			name:  "rescaled capacity",
+			group: group(SpotCapacityOptimized, 0),
+			input: modified(input(SpotCapacityOptimized, 0, "m5.large", "2", "m5.xlarge", "4"), func(in *autoscaling.UpdateAutoScalingGroupInput) {
+				in.DesiredCapacity = aws.Int64(8)
+			}),
+			want: true,
+		},
+		{
+			name:  "new launch template version",
+			group: group(SpotCapacityOptimized, 0),
+			input: modified(input(SpotCapacityOptimized, 0, "m5.large", "2", "m5.xlarge", "4"), func(in *autoscaling.UpdateAutoScalingGroupInput) {
+				in.MixedInstancesPolicy.LaunchTemplate.LaunchTemplateSpecification.Version = aws.String("2")
+			}),
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := changed(tt.group, tt.input, tt.config); got != tt.want {
+				t.Errorf("changed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
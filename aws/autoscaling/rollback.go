@@ -0,0 +1,124 @@
+package autoscaling
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+// Rollback restores the EC2 Auto Scaling group configuration saved by spotzero before the update.
+// It removes spotzero tags from the group and optionally starts an instance refresh.
+func (s *asgUpdaterService) Rollback(ctx context.Context, group *autoscaling.Group, refresh bool) error {
+	if group == nil {
+		return nil
+	}
+	log.Printf("rolling back the autoscaling group %v", *group.AutoScalingGroupARN)
+	snapshot, err := loadGroupSnapshot(group)
+	if err != nil {
+		return err
+	}
+	input := snapshot.createRestoreInput(group.AutoScalingGroupName)
+	snapshot.rescaleCapacity(group, input)
+	output, err := s.asgsvc.UpdateAutoScalingGroupWithContext(ctx, input)
+	if err != nil {
+		return fmt.Errorf("error restoring autoscaling group: %v", err)
+	}
+	log.Printf("restored autoscaling group: %v", *output)
+	// launch templates created from launch configurations are shared by groups with the same launch configuration
+	if lt := snapshot.MigratedLaunchTemplate; lt != nil {
+		log.Printf("launch template %v, created by spotzero from the launch configuration %v, is no longer used by the autoscaling group %v: delete it, unless other groups use it",
+			lt.ID, snapshot.LaunchConfigurationName, *group.AutoScalingGroupARN)
+	}
+	// remove spotzero tags from the ASG
+	err = s.deleteAutoScalingGroupTags(ctx, group)
+	if err != nil {
+		return err
+	}
+	if !refresh {
+		return nil
+	}
+	// refresh instances for the ASG with the group refresh preferences
+	config, err := s.groupConfig(group)
+	if err != nil {
+		log.Printf("using default instance refresh preferences: %v", err)
+		config = s.config
+	}
+	return s.startInstanceRefresh(ctx, group, config)
+}
+
+// saveSnapshot stores the current group configuration, the launch template migrated from the launch configuration (if any)
+// and the capacity scale applied by the update in the `spotzero:snapshot:N` tags
+func (s *asgUpdaterService) saveSnapshot(ctx context.Context, group *autoscaling.Group, migrated *autoscaling.LaunchTemplateSpecification, scale float64) error {
+	log.Printf("saving snapshot for the autoscaling group %v", *group.AutoScalingGroupARN)
+	snapshot, err := newGroupSnapshot(group)
+	if err != nil {
+		return fmt.Errorf("failed to create autoscaling group snapshot: %v", err)
+	}
+	snapshot.MigratedLaunchTemplate = newTemplateSnapshot(migrated)
+	if scale != 1 {
+		snapshot.CapacityScale = scale
+	}
+	tags, err := snapshot.tags(group.AutoScalingGroupName)
+	if err != nil {
+		return err
+	}
+	// fail before the group is updated
+	if err = checkTagsLimit(group, tags); err != nil {
+		return err
+	}
+	output, err := s.asgsvc.CreateOrUpdateTagsWithContext(ctx, &autoscaling.CreateOrUpdateTagsInput{Tags: tags})
+	if err != nil {
+		return fmt.Errorf("error saving snapshot for the autoscaling group: %v", err)
+	}
+	log.Printf("saved autoscaling group snapshot: %v", *output)
+	return s.deleteStaleSnapshotTags(ctx, group, tags)
+}
+
+// deleteStaleSnapshotTags removes snapshot chunks left on the group by an earlier, longer snapshot
+func (s *asgUpdaterService) deleteStaleSnapshotTags(ctx context.Context, group *autoscaling.Group, tags []*autoscaling.Tag) error {
+	saved := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		saved[aws.StringValue(t.Key)] = true
+	}
+	var stale []*autoscaling.Tag
+	for _, k := range snapshotTagKeys(group.Tags) {
+		if saved[k] {
+			continue
+		}
+		stale = append(stale, &autoscaling.Tag{
+			Key:          aws.String(k),
+			ResourceId:   group.AutoScalingGroupName,
+			ResourceType: aws.String("auto-scaling-group"),
+		})
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	if _, err := s.asgsvc.DeleteTagsWithContext(ctx, &autoscaling.DeleteTagsInput{Tags: stale}); err != nil {
+		return fmt.Errorf("error deleting snapshot tags for the autoscaling group: %v", err)
+	}
+	return nil
+}
+
+// deleteAutoScalingGroupTags removes spotzero updated and snapshot tags
+func (s *asgUpdaterService) deleteAutoScalingGroupTags(ctx context.Context, group *autoscaling.Group) error {
+	log.Printf("deleting spotzero tags for the autoscaling group %v", *group.AutoScalingGroupARN)
+	keys := append([]string{spotzeroUpdatedTag, spotzeroUpdatedTimeTag}, snapshotTagKeys(group.Tags)...)
+	tags := make([]*autoscaling.Tag, len(keys))
+	for i, k := range keys {
+		tags[i] = &autoscaling.Tag{
+			Key:          aws.String(k),
+			ResourceId:   group.AutoScalingGroupName,
+			ResourceType: aws.String("auto-scaling-group"),
+		}
+	}
+	output, err := s.asgsvc.DeleteTagsWithContext(ctx, &autoscaling.DeleteTagsInput{Tags: tags})
+	if err != nil {
+		return fmt.Errorf("error deleting tags for the autoscaling group: %v", err)
+	}
+	log.Printf("deleted autoscaling group tags: %v", *output)
+	return nil
+}
@@ -0,0 +1,303 @@
+package autoscaling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/doitintl/spotzero/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+// updated group: spotzero MixedInstancesPolicy with the snapshot and updated tags
+func testUpdatedGroup(tags []*autoscaling.Tag) *autoscaling.Group {
+	return &autoscaling.Group{
+		AutoScalingGroupARN:  aws.String("test-asg-arn"),
+		AutoScalingGroupName: aws.String("test-asg"),
+		MinSize:              aws.Int64(4),
+		MaxSize:              aws.Int64(8),
+		DesiredCapacity:      aws.Int64(6),
+		MixedInstancesPolicy: testMixedInstancesPolicy(4),
+		Tags:                 append(testTagDescriptions(tags), testTags("env", "dev", spotzeroUpdatedTag, "true")...),
+	}
+}
+
+// keys of the tags deleted on rollback
+func testDeletedTags(keys ...string) *autoscaling.DeleteTagsInput {
+	input := &autoscaling.DeleteTagsInput{}
+	for _, k := range append([]string{spotzeroUpdatedTag, spotzeroUpdatedTimeTag}, keys...) {
+		input.Tags = append(input.Tags, &autoscaling.Tag{
+			Key:          aws.String(k),
+			ResourceId:   aws.String("test-asg"),
+			ResourceType: aws.String("auto-scaling-group"),
+		})
+	}
+	return input
+}
+
+//nolint:funlen
+func Test_asgUpdaterService_saveSnapshot_Rollback(t *testing.T) {
+	tests := []struct {
+		name     string
+		group    *autoscaling.Group
+		migrated *autoscaling.LaunchTemplateSpecification
+		scale    float64
+		want     *autoscaling.UpdateAutoScalingGroupInput
+		wantTags int
+	}{
+		{
+			name: "migrated launch configuration",
+			group: &autoscaling.Group{
+				AutoScalingGroupARN:     aws.String("test-asg-arn"),
+				AutoScalingGroupName:    aws.String("test-asg"),
+				LaunchConfigurationName: aws.String("test-lc"),
+			},
+			migrated: &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-0123456789"), Version: aws.String("1")},
+			scale:    1,
+			want: &autoscaling.UpdateAutoScalingGroupInput{
+				AutoScalingGroupName:    aws.String("test-asg"),
+				CapacityRebalance:       aws.Bool(false),
+				LaunchConfigurationName: aws.String("test-lc"),
+				MaxInstanceLifetime:     aws.Int64(0),
+				DesiredCapacityType:     aws.String("units"),
+			},
+			wantTags: 1,
+		},
+		{
+			name: "multi-part snapshot with rescaled capacity",
+			group: &autoscaling.Group{
+				AutoScalingGroupARN:  aws.String("test-asg-arn"),
+				AutoScalingGroupName: aws.String("test-asg"),
+				CapacityRebalance:    aws.Bool(true),
+				MixedInstancesPolicy: testMixedInstancesPolicy(20),
+			},
+			scale: 2,
+			want: func() *autoscaling.UpdateAutoScalingGroupInput {
+				mip := testMixedInstancesPolicy(20)
+				mip.LaunchTemplate.LaunchTemplateSpecification.LaunchTemplateName = nil
+				return &autoscaling.UpdateAutoScalingGroupInput{
+					AutoScalingGroupName: aws.String("test-asg"),
+					CapacityRebalance:    aws.Bool(true),
+					MaxInstanceLifetime:  aws.Int64(0),
+					DesiredCapacityType:  aws.String("units"),
+					MixedInstancesPolicy: mip,
+					MinSize:              aws.Int64(2),
+					MaxSize:              aws.Int64(4),
+					DesiredCapacity:      aws.Int64(3),
+				}
+			}(),
+			wantTags: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mockAsgSvc := new(mocks.AwsAsgUpdater)
+			s := &asgUpdaterService{asgsvc: mockAsgSvc}
+			var saved []*autoscaling.Tag
+			mockAsgSvc.On("CreateOrUpdateTagsWithContext", ctx, mock.AnythingOfType("*autoscaling.CreateOrUpdateTagsInput")).
+				Run(func(args mock.Arguments) {
+					saved = args.Get(1).(*autoscaling.CreateOrUpdateTagsInput).Tags
+				}).
+				Return(&autoscaling.CreateOrUpdateTagsOutput{}, nil).Once()
+			if err := s.saveSnapshot(ctx, tt.group, tt.migrated, tt.scale); err != nil {
+				t.Errorf("saveSnapshot() error = %v", err)
+				return
+			}
+			if len(saved) != tt.wantTags {
+				t.Errorf("saveSnapshot() saved %d tags, want %d", len(saved), tt.wantTags)
+			}
+			keys := make([]string, len(saved))
+			for i, tag := range saved {
+				keys[i] = aws.StringValue(tag.Key)
+			}
+			mockAsgSvc.On("UpdateAutoScalingGroupWithContext", ctx, tt.want).Return(&autoscaling.UpdateAutoScalingGroupOutput{}, nil).Once()
+			mockAsgSvc.On("DeleteTagsWithContext", ctx, testDeletedTags(keys...)).Return(&autoscaling.DeleteTagsOutput{}, nil).Once()
+			if err := s.Rollback(ctx, testUpdatedGroup(saved), false); err != nil {
+				t.Errorf("Rollback() error = %v", err)
+			}
+			mockAsgSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_asgUpdaterService_saveSnapshot_tagsLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []*autoscaling.TagDescription
+		wantErr bool
+	}{
+		{
+			name: "tags fit into the limit",
+			// 47 group tags + 1 snapshot tag + 2 updated tags
+			tags: createNTagDescriptions(47),
+		},
+		{
+			name:    "fail: too many tags",
+			tags:    createNTagDescriptions(48),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mockAsgSvc := new(mocks.AwsAsgUpdater)
+			s := &asgUpdaterService{asgsvc: mockAsgSvc}
+			if !tt.wantErr {
+				mockAsgSvc.On("CreateOrUpdateTagsWithContext", ctx, mock.AnythingOfType("*autoscaling.CreateOrUpdateTagsInput")).
+					Return(&autoscaling.CreateOrUpdateTagsOutput{}, nil).Once()
+			}
+			group := &autoscaling.Group{
+				AutoScalingGroupARN:     aws.String("test-asg-arn"),
+				AutoScalingGroupName:    aws.String("test-asg"),
+				LaunchConfigurationName: aws.String("test-lc"),
+				Tags:                    tt.tags,
+			}
+			if err := s.saveSnapshot(ctx, group, nil, 1); (err != nil) != tt.wantErr {
+				t.Errorf("saveSnapshot() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			mockAsgSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_asgUpdaterService_saveSnapshot_staleChunks(t *testing.T) {
+	ctx := context.TODO()
+	// earlier 3-part snapshot, left after a partly failed update
+	previous, err := newGroupSnapshot(&autoscaling.Group{MixedInstancesPolicy: testMixedInstancesPolicy(20)})
+	if err != nil {
+		t.Fatalf("newGroupSnapshot() error = %v", err)
+	}
+	long, err := previous.tags(aws.String("test-asg"))
+	if err != nil || len(long) != 3 {
+		t.Fatalf("tags() = %d tags, error = %v", len(long), err)
+	}
+	group := &autoscaling.Group{
+		AutoScalingGroupARN:     aws.String("test-asg-arn"),
+		AutoScalingGroupName:    aws.String("test-asg"),
+		LaunchConfigurationName: aws.String("test-lc"),
+		Tags:                    testTagDescriptions(long),
+	}
+	mockAsgSvc := new(mocks.AwsAsgUpdater)
+	s := &asgUpdaterService{asgsvc: mockAsgSvc}
+	var saved []*autoscaling.Tag
+	mockAsgSvc.On("CreateOrUpdateTagsWithContext", ctx, mock.AnythingOfType("*autoscaling.CreateOrUpdateTagsInput")).
+		Run(func(args mock.Arguments) {
+			saved = args.Get(1).(*autoscaling.CreateOrUpdateTagsInput).Tags
+		}).
+		Return(&autoscaling.CreateOrUpdateTagsOutput{}, nil).Once()
+	stale := &autoscaling.DeleteTagsInput{}
+	for _, k := range []string{spotzeroSnapshotTag + ":1", spotzeroSnapshotTag + ":2"} {
+		stale.Tags = append(stale.Tags, &autoscaling.Tag{
+			Key:          aws.String(k),
+			ResourceId:   aws.String("test-asg"),
+			ResourceType: aws.String("auto-scaling-group"),
+		})
+	}
+	mockAsgSvc.On("DeleteTagsWithContext", ctx, stale).Return(&autoscaling.DeleteTagsOutput{}, nil).Once()
+	if err = s.saveSnapshot(ctx, group, nil, 1); err != nil {
+		t.Fatalf("saveSnapshot() error = %v", err)
+	}
+	mockAsgSvc.AssertExpectations(t)
+	if len(saved) != 1 {
+		t.Fatalf("saveSnapshot() saved %d tags, want 1", len(saved))
+	}
+	snapshot, err := loadGroupSnapshot(&autoscaling.Group{Tags: testTagDescriptions(saved)})
+	if err != nil {
+		t.Fatalf("loadGroupSnapshot() error = %v", err)
+	}
+	if snapshot.LaunchConfigurationName != "test-lc" || snapshot.MixedInstancesPolicy != nil {
+		t.Errorf("loadGroupSnapshot() = %+v, want launch configuration snapshot", snapshot)
+	}
+}
+
+func Test_asgUpdaterService_Rollback(t *testing.T) {
+	snapshot := &groupSnapshot{LaunchConfigurationName: "test-lc"}
+	tags, err := snapshot.tags(aws.String("test-asg"))
+	if err != nil {
+		t.Fatalf("tags() error = %v", err)
+	}
+	tests := []struct {
+		name      string
+		tags      []*autoscaling.Tag
+		updateErr error
+		deleteErr error
+		wantErr   bool
+	}{
+		{
+			name: "restore launch configuration",
+			tags: tags,
+		},
+		{
+			name:    "fail: missing snapshot",
+			wantErr: true,
+		},
+		{
+			name: "fail: truncated snapshot",
+			tags: []*autoscaling.Tag{
+				{Key: aws.String(spotzeroSnapshotTag + ":0"), Value: aws.String(`{"lc":"test-`)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail: missing snapshot part",
+			tags: []*autoscaling.Tag{
+				{Key: aws.String(spotzeroSnapshotTag + ":0"), Value: aws.String(`{"lc":"test-`)},
+				{Key: aws.String(spotzeroSnapshotTag + ":2"), Value: aws.String(`lc"}`)},
+			},
+			wantErr: true,
+		},
+		{
+			name:      "fail: restore group",
+			tags:      tags,
+			updateErr: errors.New("test"),
+			wantErr:   true,
+		},
+		{
+			name:      "fail: delete tags",
+			tags:      tags,
+			deleteErr: errors.New("test"),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mockAsgSvc := new(mocks.AwsAsgUpdater)
+			s := &asgUpdaterService{asgsvc: mockAsgSvc}
+			group := testUpdatedGroup(tt.tags)
+			if _, err := loadGroupSnapshot(group); err == nil {
+				mockAsgSvc.On("UpdateAutoScalingGroupWithContext", ctx, mock.AnythingOfType("*autoscaling.UpdateAutoScalingGroupInput")).
+					Return(&autoscaling.UpdateAutoScalingGroupOutput{}, tt.updateErr).Once()
+				if tt.updateErr == nil {
+					mockAsgSvc.On("DeleteTagsWithContext", ctx, testDeletedTags(spotzeroSnapshotTag+":0")).
+						Return(&autoscaling.DeleteTagsOutput{}, tt.deleteErr).Once()
+				}
+			}
+			if err := s.Rollback(ctx, group, false); (err != nil) != tt.wantErr {
+				t.Errorf("Rollback() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			mockAsgSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_asgUpdaterService_deleteAutoScalingGroupTags(t *testing.T) {
+	ctx := context.TODO()
+	mockAsgSvc := new(mocks.AwsAsgUpdater)
+	s := &asgUpdaterService{asgsvc: mockAsgSvc}
+	// snapshot chunks are deleted in order, other group tags are kept
+	var tags []*autoscaling.Tag
+	for _, i := range []int{10, 2, 0, 1} {
+		tags = append(tags, &autoscaling.Tag{Key: aws.String(fmt.Sprintf("%s:%d", spotzeroSnapshotTag, i)), Value: aws.String("test")})
+	}
+	want := testDeletedTags(spotzeroSnapshotTag+":0", spotzeroSnapshotTag+":1", spotzeroSnapshotTag+":2", spotzeroSnapshotTag+":10")
+	mockAsgSvc.On("DeleteTagsWithContext", ctx, want).Return(&autoscaling.DeleteTagsOutput{}, nil).Once()
+	if err := s.deleteAutoScalingGroupTags(ctx, testUpdatedGroup(tags)); err != nil {
+		t.Errorf("deleteAutoScalingGroupTags() error = %v", err)
+	}
+	mockAsgSvc.AssertExpectations(t)
+}
@@ -0,0 +1,318 @@
+package autoscaling
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+type operator int
+
+const (
+	opExists operator = iota
+	opNotExists
+	opEquals
+	opNotEquals
+	opIn
+	opNotIn
+)
+
+const (
+	orSeparator  = "||"
+	andSeparator = ','
+	wildcard     = "*"
+)
+
+var setRequirementRegexp = regexp.MustCompile(`^(.+?)\s+(in|notin)\s*\((.*)\)$`)
+
+// requirement is a single tag condition, like `env=dev` or `env in (dev,qa)`
+type requirement struct {
+	key    string
+	op     operator
+	values []string
+}
+
+// A Selector selects EC2 Auto Scaling groups by tags.
+// Selector is a list of OR'ed groups of AND'ed tag requirements; an empty Selector matches all groups.
+type Selector struct {
+	groups [][]requirement
+}
+
+// ParseSelector parses the tag selector expression.
+// Supported requirements:
+//  key=value, key!=value     value equality; value can contain `*` wildcard (`env=dev*`)
+//  key, !key                 tag existence
+//  key in (v1,v2)            value set; values can contain `*` wildcard
+//  key notin (v1,v2)         excluded value set
+// Keys can contain spaces (`Cost Center=eng`), but not `!`, `=` and parentheses.
+// Requirements are AND'ed with `,` and AND groups are OR'ed with `||`, for example
+//  env in (dev,qa),team || env=prod,!legacy
+// It returns an error for a malformed expression.
+func ParseSelector(expr string) (Selector, error) {
+	var selector Selector
+	if strings.TrimSpace(expr) == "" {
+		return selector, nil
+	}
+	for _, g := range strings.Split(expr, orSeparator) {
+		parts, err := splitRequirements(g)
+		if err != nil {
+			return Selector{}, fmt.Errorf("invalid tag selector %q: %v", expr, err)
+		}
+		group := make([]requirement, len(parts))
+		for i, p := range parts {
+			group[i], err = parseRequirement(p)
+			if err != nil {
+				return Selector{}, fmt.Errorf("invalid tag selector %q: %v", expr, err)
+			}
+		}
+		selector.groups = append(selector.groups, group)
+	}
+	return selector, nil
+}
+
+// split AND group by commas, ignoring commas inside value set parentheses
+func splitRequirements(group string) ([]string, error) {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range group {
+		switch c {
+		case '(':
+			depth++
+			if depth > 1 {
+				return nil, fmt.Errorf("nested parentheses in %q", strings.TrimSpace(group))
+			}
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses in %q", strings.TrimSpace(group))
+			}
+		case andSeparator:
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(group[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses in %q", strings.TrimSpace(group))
+	}
+	parts = append(parts, strings.TrimSpace(group[start:]))
+	for _, p := range parts {
+		if p == "" {
+			return nil, fmt.Errorf("empty requirement in %q", strings.TrimSpace(group))
+		}
+	}
+	return parts, nil
+}
+
+func parseRequirement(s string) (requirement, error) {
+	if m := setRequirementRegexp.FindStringSubmatch(s); m != nil {
+		r := requirement{key: m[1], op: opIn}
+		if m[2] == "notin" {
+			r.op = opNotIn
+		}
+		for _, v := range strings.Split(m[3], ",") {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				return requirement{}, fmt.Errorf("empty value in %q", s)
+			}
+			r.values = append(r.values, v)
+		}
+		return r, validateKey(r.key, s)
+	}
+	if strings.ContainsAny(s, "()") {
+		return requirement{}, fmt.Errorf("unexpected parentheses in %q", s)
+	}
+	if kv := strings.SplitN(s, "!=", 2); len(kv) == 2 {
+		r := requirement{key: strings.TrimSpace(kv[0]), op: opNotEquals, values: []string{strings.TrimSpace(kv[1])}}
+		return r, validateKey(r.key, s)
+	}
+	if kv := strings.SplitN(s, "=", 2); len(kv) == 2 {
+		r := requirement{key: strings.TrimSpace(kv[0]), op: opEquals, values: []string{strings.TrimSpace(kv[1])}}
+		return r, validateKey(r.key, s)
+	}
+	if strings.HasPrefix(s, "!") {
+		r := requirement{key: strings.TrimSpace(s[1:]), op: opNotExists}
+		return r, validateKey(r.key, s)
+	}
+	return requirement{key: s, op: opExists}, validateKey(s, s)
+}
+
+func validateKey(key, s string) error {
+	if key == "" {
+		return fmt.Errorf("empty tag key in %q", s)
+	}
+	if strings.ContainsAny(key, "!=()") {
+		return fmt.Errorf("invalid tag key %q in %q", key, s)
+	}
+	return nil
+}
+
+// matchValue compares tag value with the pattern; `*` in pattern matches any sequence of characters
+func matchValue(pattern, value string) bool {
+	if !strings.Contains(pattern, wildcard) {
+		return pattern == value
+	}
+	parts := strings.Split(pattern, wildcard)
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	for _, p := range parts[1 : len(parts)-1] {
+		i := strings.Index(value, p)
+		if i < 0 {
+			return false
+		}
+		value = value[i+len(p):]
+	}
+	return strings.HasSuffix(value, parts[len(parts)-1])
+}
+
+func (r requirement) matchAny(value string) bool {
+	for _, v := range r.values {
+		if matchValue(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r requirement) matches(tags map[string]string) bool {
+	value, found := tags[r.key]
+	switch r.op {
+	case opExists:
+		return found
+	case opNotExists:
+		return !found
+	case opEquals, opIn:
+		return found && r.matchAny(value)
+	case opNotEquals, opNotIn:
+		return !found || !r.matchAny(value)
+	}
+	return false
+}
+
+func (r requirement) String() string {
+	switch r.op {
+	case opExists:
+		return r.key
+	case opNotExists:
+		return "!" + r.key
+	case opEquals:
+		return r.key + "=" + r.values[0]
+	case opNotEquals:
+		return r.key + "!=" + r.values[0]
+	case opIn:
+		return r.key + " in (" + strings.Join(r.values, ",") + ")"
+	case opNotIn:
+		return r.key + " notin (" + strings.Join(r.values, ",") + ")"
+	}
+	return ""
+}
+
+// Empty returns true if Selector has no requirements and matches all groups
+func (s Selector) Empty() bool {
+	return len(s.groups) == 0
+}
+
+// And returns a Selector matching groups matched by both Selectors
+func (s Selector) And(other Selector) Selector {
+	if s.Empty() {
+		return other
+	}
+	if other.Empty() {
+		return s
+	}
+	var and Selector
+	for _, a := range s.groups {
+		for _, b := range other.groups {
+			group := make([]requirement, 0, len(a)+len(b))
+			and.groups = append(and.groups, append(append(group, a...), b...))
+		}
+	}
+	return and
+}
+
+// Matches returns true if EC2 Auto Scaling group tags match the Selector
+func (s Selector) Matches(actual []*autoscaling.TagDescription) bool {
+	if s.Empty() {
+		return true
+	}
+	tags := make(map[string]string, len(actual))
+	for _, t := range actual {
+		tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+	for _, group := range s.groups {
+		matched := true
+		for _, r := range group {
+			if !r.matches(tags) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the canonical Selector expression
+func (s Selector) String() string {
+	groups := make([]string, len(s.groups))
+	for i, group := range s.groups {
+		requirements := make([]string, len(group))
+		for j, r := range group {
+			requirements[j] = r.String()
+		}
+		groups[i] = strings.Join(requirements, string(andSeparator))
+	}
+	return strings.Join(groups, " "+orSeparator+" ")
+}
+
+// groupFilters returns DescribeAutoScalingGroups filters for every OR group of the Selector.
+// Exact equality, value set and existence requirements are matched on the server side with `tag:<key>`
+// and `tag-key` filters; wildcards and negations are left for the client side Matches check.
+// A single nil filter list is returned, if some group has no server side requirements (full scan).
+func (s Selector) groupFilters() [][]*autoscaling.Filter {
+	var groups [][]*autoscaling.Filter
+	for _, group := range s.groups {
+		var filters []*autoscaling.Filter
+		for _, r := range group {
+			switch r.op {
+			case opExists:
+				filters = append(filters, &autoscaling.Filter{
+					Name:   aws.String("tag-key"),
+					Values: aws.StringSlice([]string{r.key}),
+				})
+			case opEquals, opIn:
+				if !hasWildcard(r.values) {
+					filters = append(filters, &autoscaling.Filter{
+						Name:   aws.String("tag:" + r.key),
+						Values: aws.StringSlice(r.values),
+					})
+				}
+			}
+		}
+		if len(filters) == 0 {
+			return [][]*autoscaling.Filter{nil}
+		}
+		groups = append(groups, filters)
+	}
+	if len(groups) == 0 {
+		return [][]*autoscaling.Filter{nil}
+	}
+	return groups
+}
+
+func hasWildcard(values []string) bool {
+	for _, v := range values {
+		if strings.Contains(v, wildcard) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package autoscaling
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+func Test_ParseSelector(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", expr: " ", want: ""},
+		{name: "exact match", expr: "env=dev", want: "env=dev"},
+		{name: "negation", expr: "env != prod", want: "env!=prod"},
+		{name: "existence", expr: "team,!legacy", want: "team,!legacy"},
+		{name: "wildcard", expr: "env=dev*", want: "env=dev*"},
+		{name: "value set", expr: "env in (dev, qa),team", want: "env in (dev,qa),team"},
+		{name: "excluded value set", expr: "env notin(prod)", want: "env notin (prod)"},
+		{name: "or groups", expr: "env=dev,team || env in (qa,stage)", want: "env=dev,team || env in (qa,stage)"},
+		{name: "empty value", expr: "env=", want: "env="},
+		{name: "fail: empty requirement", expr: "env=dev,", wantErr: true},
+		{name: "fail: empty or group", expr: "env=dev ||", wantErr: true},
+		{name: "fail: empty key", expr: "=dev", wantErr: true},
+		{name: "fail: unbalanced parentheses", expr: "env in (dev,qa", wantErr: true},
+		{name: "fail: nested parentheses", expr: "env in ((dev))", wantErr: true},
+		{name: "fail: empty set value", expr: "env in (dev,,qa)", wantErr: true},
+		{name: "fail: unknown operator", expr: "env of (dev)", wantErr: true},
+		{name: "key with spaces", expr: "Cost Center=eng, Cost Center in (ops), my env", want: "Cost Center=eng,Cost Center in (ops),my env"},
+		{name: "fail: key with parentheses", expr: "env(1)=dev", wantErr: true},
+		{name: "fail: key with negation", expr: "e!nv", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSelector(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseSelector() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got.String() != tt.want {
+				t.Errorf("ParseSelector() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func testTags(kv ...string) []*autoscaling.TagDescription {
+	var tags []*autoscaling.TagDescription
+	for i := 0; i < len(kv); i += 2 {
+		tags = append(tags, &autoscaling.TagDescription{Key: aws.String(kv[i]), Value: aws.String(kv[i+1])})
+	}
+	return tags
+}
+
+func TestSelector_Matches(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		tags []*autoscaling.TagDescription
+		want bool
+	}{
+		{"empty selector should match", "", testTags("env", "dev"), true},
+		{"exact match", "env=dev,team=web", testTags("env", "dev", "team", "web"), true},
+		{"exact match should fail", "env=dev,team=web", testTags("env", "dev"), false},
+		{"negation", "env!=prod", testTags("env", "dev"), true},
+		{"negation of missing tag", "env!=prod", testTags("team", "web"), true},
+		{"negation should fail", "env!=prod", testTags("env", "prod"), false},
+		{"existence", "team", testTags("team", ""), true},
+		{"existence should fail", "team", testTags("env", "dev"), false},
+		{"non existence", "!legacy", testTags("env", "dev"), true},
+		{"wildcard prefix", "env=dev*", testTags("env", "dev-1"), true},
+		{"wildcard middle", "env=d*v", testTags("env", "dev-v"), true},
+		{"wildcard should fail", "env=dev*", testTags("env", "qa-dev"), false},
+		{"value set", "env in (dev,qa)", testTags("env", "qa"), true},
+		{"value set with wildcard", "env in (dev,qa*)", testTags("env", "qa-2"), true},
+		{"value set should fail", "env in (dev,qa)", testTags("env", "prod"), false},
+		{"excluded value set", "env notin (dev,qa)", testTags("env", "prod"), true},
+		{"excluded value set should fail", "env notin (dev,qa)", testTags("env", "dev"), false},
+		{"second or group", "env=dev,team || env=prod", testTags("env", "prod"), true},
+		{"or groups should fail", "env=dev,team || env=prod", testTags("env", "dev"), false},
+		{"no tags should fail", "env=dev", nil, false},
+		{"key with spaces", "Cost Center in (eng,ops)", testTags("Cost Center", "ops"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := ParseSelector(tt.expr)
+			if err != nil {
+				t.Errorf("ParseSelector() error = %v", err)
+				return
+			}
+			if got := selector.Matches(tt.tags); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelector_And(t *testing.T) {
+	tests := []struct {
+		name  string
+		exprs []string
+		want  string
+	}{
+		{name: "empty selectors", exprs: []string{"", ""}, want: ""},
+		{name: "single selector", exprs: []string{"", "env=dev || env=qa"}, want: "env=dev || env=qa"},
+		{name: "and of requirements", exprs: []string{"env=dev", "team"}, want: "env=dev,team"},
+		{
+			name:  "or groups are and'ed as units",
+			exprs: []string{"env=dev || env=qa", "team=web || !legacy"},
+			want:  "env=dev,team=web || env=dev,!legacy || env=qa,team=web || env=qa,!legacy",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Selector
+			for _, expr := range tt.exprs {
+				selector, err := ParseSelector(expr)
+				if err != nil {
+					t.Fatalf("ParseSelector() error = %v", err)
+				}
+				got = got.And(selector)
+			}
+			if got.String() != tt.want {
+				t.Errorf("And() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelector_groupFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want [][]*autoscaling.Filter
+	}{
+		{
+			name: "empty selector",
+			expr: "",
+			want: [][]*autoscaling.Filter{nil},
+		},
+		{
+			name: "exact values and existence",
+			expr: "team,env=dev,!legacy || env in (qa,stage)",
+			want: [][]*autoscaling.Filter{
+				{
+					{Name: aws.String("tag-key"), Values: aws.StringSlice([]string{"team"})},
+					{Name: aws.String("tag:env"), Values: aws.StringSlice([]string{"dev"})},
+				},
+				{
+					{Name: aws.String("tag:env"), Values: aws.StringSlice([]string{"qa", "stage"})},
+				},
+			},
+		},
+		{
+			name: "wildcard values",
+			expr: "env=dev*,team in (web*,api)",
+			want: [][]*autoscaling.Filter{nil},
+		},
+		{
+			name: "group without server side requirements",
+			expr: "env=dev || !legacy",
+			want: [][]*autoscaling.Filter{nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := ParseSelector(tt.expr)
+			if err != nil {
+				t.Errorf("ParseSelector() error = %v", err)
+				return
+			}
+			if got := selector.groupFilters(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupFilters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
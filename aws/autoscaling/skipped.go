@@ -0,0 +1,31 @@
+package autoscaling
+
+import (
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+// SkipReason is a machine readable reason for skipping an EC2 Auto Scaling group
+type SkipReason string
+
+const (
+	// SkipAlreadyUpdated group is already updated by spotzero ("spotzero:updated=true" tag)
+	SkipAlreadyUpdated SkipReason = "already-updated"
+	// SkipDeleting group is being deleted
+	SkipDeleting SkipReason = "deleting"
+	// SkipLaunchConfiguration group uses launch configuration instead of launch template
+	SkipLaunchConfiguration SkipReason = "launch-configuration"
+	// SkipSpotLaunchTemplate group launch template already requests Spot instances
+	SkipSpotLaunchTemplate SkipReason = "spot-launch-template"
+	// SkipExcludedByPolicy group is excluded by spotzero configuration
+	SkipExcludedByPolicy SkipReason = "excluded-by-policy"
+)
+
+// A SkippedGroup is an EC2 Auto Scaling group, that matches the filter, but is not eligible for update
+type SkippedGroup struct {
+	// Group skipped EC2 Auto Scaling group
+	Group *autoscaling.Group `json:"group"`
+	// Reason machine readable skip reason
+	Reason SkipReason `json:"reason"`
+	// Message human readable skip details
+	Message string `json:"message,omitempty"`
+}
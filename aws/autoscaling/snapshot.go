@@ -0,0 +1,313 @@
+package autoscaling
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+const (
+	// spotzero snapshot tag prefix; snapshot is split into `spotzero:snapshot:N` tags
+	spotzeroSnapshotTag = "spotzero:snapshot"
+	// maximum length of the EC2 Auto Scaling tag value
+	maxTagValueLength = 256
+	// maximum number of tags per EC2 Auto Scaling group
+	maxGroupTags = 50
+)
+
+// groupSnapshot keeps the EC2 Auto Scaling group configuration replaced by spotzero.
+// Short JSON names are used to keep the number of snapshot tags low.
+type groupSnapshot struct {
+	// CapacityRebalance the original Capacity Rebalancing setting; nil in snapshots taken before spotzero managed it
+	CapacityRebalance *bool `json:"cr,omitempty"`
+	// MaxInstanceLifetime the original maximum instance lifetime (0 - not set); nil in snapshots taken before spotzero managed it
+	MaxInstanceLifetime *int64 `json:"mil,omitempty"`
+	// DesiredCapacityType the original desired capacity type; nil in snapshots taken before spotzero managed it
+	DesiredCapacityType *string `json:"dct,omitempty"`
+	// CapacityScale the ratio applied to the group min size, max size and desired capacity by spotzero (0 - not rescaled)
+	CapacityScale float64 `json:"cs,omitempty"`
+	// LaunchConfigurationName launch configuration of the group, replaced with a launch template by spotzero
+	LaunchConfigurationName string `json:"lc,omitempty"`
+	// MigratedLaunchTemplate launch template created by spotzero from the launch configuration
+	MigratedLaunchTemplate *templateSnapshot `json:"mlt,omitempty"`
+	// LaunchTemplate launch template attached directly to the group (no MixedInstancesPolicy)
+	LaunchTemplate *templateSnapshot `json:"lt,omitempty"`
+	// MixedInstancesPolicy the original MixedInstancesPolicy of the group
+	MixedInstancesPolicy *policySnapshot `json:"mip,omitempty"`
+}
+
+type templateSnapshot struct {
+	ID      string `json:"id,omitempty"`
+	Name    string `json:"n,omitempty"`
+	Version string `json:"v,omitempty"`
+}
+
+type policySnapshot struct {
+	LaunchTemplate *templateSnapshot     `json:"lt,omitempty"`
+	Distribution   *distributionSnapshot `json:"d,omitempty"`
+	Overrides      []overrideSnapshot    `json:"o,omitempty"`
+}
+
+type distributionSnapshot struct {
+	OnDemandAllocationStrategy          *string `json:"oas,omitempty"`
+	OnDemandBaseCapacity                *int64  `json:"obc,omitempty"`
+	OnDemandPercentageAboveBaseCapacity *int64  `json:"opabc,omitempty"`
+	SpotAllocationStrategy              *string `json:"sas,omitempty"`
+	SpotInstancePools                   *int64  `json:"sip,omitempty"`
+	SpotMaxPrice                        *string `json:"smp,omitempty"`
+}
+
+type overrideSnapshot struct {
+	InstanceType     string            `json:"t"`
+	WeightedCapacity string            `json:"w,omitempty"`
+	LaunchTemplate   *templateSnapshot `json:"lt,omitempty"`
+	// Requirements instance requirements override (no instance type)
+	Requirements *autoscaling.InstanceRequirements `json:"ir,omitempty"`
+}
+
+func newTemplateSnapshot(spec *autoscaling.LaunchTemplateSpecification) *templateSnapshot {
+	if spec == nil {
+		return nil
+	}
+	return &templateSnapshot{
+		ID:      aws.StringValue(spec.LaunchTemplateId),
+		Name:    aws.StringValue(spec.LaunchTemplateName),
+		Version: aws.StringValue(spec.Version),
+	}
+}
+
+func (t *templateSnapshot) spec() *autoscaling.LaunchTemplateSpecification {
+	if t == nil {
+		return nil
+	}
+	// launch template ID and name are mutually exclusive
+	spec := &autoscaling.LaunchTemplateSpecification{}
+	if t.ID != "" {
+		spec.LaunchTemplateId = aws.String(t.ID)
+	} else {
+		spec.LaunchTemplateName = aws.String(t.Name)
+	}
+	if t.Version != "" {
+		spec.Version = aws.String(t.Version)
+	}
+	return spec
+}
+
+// newGroupSnapshot takes a snapshot of the group launch configuration or launch template, instances distribution and overrides,
+// Capacity Rebalancing, maximum instance lifetime and desired capacity type
+func newGroupSnapshot(group *autoscaling.Group) (*groupSnapshot, error) {
+	if group == nil {
+		return nil, errors.New("error autoscaling group is nil")
+	}
+	snapshot, err := newLaunchSnapshot(group)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.CapacityRebalance = aws.Bool(aws.BoolValue(group.CapacityRebalance))
+	snapshot.MaxInstanceLifetime = aws.Int64(aws.Int64Value(group.MaxInstanceLifetime))
+	snapshot.DesiredCapacityType = aws.String(capacityTypeUnits)
+	if group.DesiredCapacityType != nil {
+		snapshot.DesiredCapacityType = group.DesiredCapacityType
+	}
+	return snapshot, nil
+}
+
+// newLaunchSnapshot takes a snapshot of the group launch configuration or launch template, instances distribution and overrides
+func newLaunchSnapshot(group *autoscaling.Group) (*groupSnapshot, error) {
+	if group.LaunchConfigurationName != nil {
+		return &groupSnapshot{LaunchConfigurationName: *group.LaunchConfigurationName}, nil
+	}
+	if group.LaunchTemplate != nil {
+		return &groupSnapshot{LaunchTemplate: newTemplateSnapshot(group.LaunchTemplate)}, nil
+	}
+	mip := group.MixedInstancesPolicy
+	if mip == nil || mip.LaunchTemplate == nil {
+		return nil, fmt.Errorf("failed to find launch template attached to the autoscaling group: %v", aws.StringValue(group.AutoScalingGroupARN))
+	}
+	policy := &policySnapshot{LaunchTemplate: newTemplateSnapshot(mip.LaunchTemplate.LaunchTemplateSpecification)}
+	if d := mip.InstancesDistribution; d != nil {
+		policy.Distribution = &distributionSnapshot{
+			OnDemandAllocationStrategy:          d.OnDemandAllocationStrategy,
+			OnDemandBaseCapacity:                d.OnDemandBaseCapacity,
+			OnDemandPercentageAboveBaseCapacity: d.OnDemandPercentageAboveBaseCapacity,
+			SpotAllocationStrategy:              d.SpotAllocationStrategy,
+			SpotInstancePools:                   d.SpotInstancePools,
+			SpotMaxPrice:                        d.SpotMaxPrice,
+		}
+	}
+	for _, o := range mip.LaunchTemplate.Overrides {
+		policy.Overrides = append(policy.Overrides, overrideSnapshot{
+			InstanceType:     aws.StringValue(o.InstanceType),
+			WeightedCapacity: aws.StringValue(o.WeightedCapacity),
+			LaunchTemplate:   newTemplateSnapshot(o.LaunchTemplateSpecification),
+			Requirements:     o.InstanceRequirements,
+		})
+	}
+	return &groupSnapshot{MixedInstancesPolicy: policy}, nil
+}
+
+// createRestoreInput creates UpdateAutoScalingGroupInput request, that restores the snapshot configuration
+func (s *groupSnapshot) createRestoreInput(groupName *string) *autoscaling.UpdateAutoScalingGroupInput {
+	input := &autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: groupName,
+		CapacityRebalance:    s.CapacityRebalance,
+		// 0 clears the maximum instance lifetime
+		MaxInstanceLifetime: s.MaxInstanceLifetime,
+		DesiredCapacityType: s.DesiredCapacityType,
+	}
+	if s.LaunchConfigurationName != "" {
+		input.LaunchConfigurationName = aws.String(s.LaunchConfigurationName)
+		return input
+	}
+	if s.MixedInstancesPolicy == nil {
+		input.LaunchTemplate = s.LaunchTemplate.spec()
+		return input
+	}
+	policy := s.MixedInstancesPolicy
+	// empty (non nil) overrides list replaces overrides set by spotzero
+	overrides := make([]*autoscaling.LaunchTemplateOverrides, len(policy.Overrides))
+	for i, o := range policy.Overrides {
+		overrides[i] = &autoscaling.LaunchTemplateOverrides{
+			InstanceRequirements:        o.Requirements,
+			LaunchTemplateSpecification: o.LaunchTemplate.spec(),
+		}
+		if o.InstanceType != "" {
+			overrides[i].InstanceType = aws.String(o.InstanceType)
+		}
+		if o.WeightedCapacity != "" {
+			overrides[i].WeightedCapacity = aws.String(o.WeightedCapacity)
+		}
+	}
+	input.MixedInstancesPolicy = &autoscaling.MixedInstancesPolicy{
+		LaunchTemplate: &autoscaling.LaunchTemplate{
+			LaunchTemplateSpecification: policy.LaunchTemplate.spec(),
+			Overrides:                   overrides,
+		},
+	}
+	if d := policy.Distribution; d != nil {
+		input.MixedInstancesPolicy.InstancesDistribution = &autoscaling.InstancesDistribution{
+			OnDemandAllocationStrategy:          d.OnDemandAllocationStrategy,
+			OnDemandBaseCapacity:                d.OnDemandBaseCapacity,
+			OnDemandPercentageAboveBaseCapacity: d.OnDemandPercentageAboveBaseCapacity,
+			SpotAllocationStrategy:              d.SpotAllocationStrategy,
+			SpotInstancePools:                   d.SpotInstancePools,
+			SpotMaxPrice:                        d.SpotMaxPrice,
+		}
+	}
+	return input
+}
+
+// rescaleCapacity converts the group min size, max size and desired capacity back into the original capacity units
+func (s *groupSnapshot) rescaleCapacity(group *autoscaling.Group, input *autoscaling.UpdateAutoScalingGroupInput) {
+	if s.CapacityScale > 0 {
+		rescaleCapacity(group, input, 1/s.CapacityScale)
+	}
+}
+
+// tags serializes the snapshot into JSON and splits it into `spotzero:snapshot:N` tags
+func (s *groupSnapshot) tags(groupName *string) ([]*autoscaling.Tag, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("error converting autoscaling group snapshot to JSON: %v", err)
+	}
+	var tags []*autoscaling.Tag
+	value := string(data)
+	for i := 0; len(value) > 0; i++ {
+		n := len(value)
+		if n > maxTagValueLength {
+			n = maxTagValueLength
+			// do not split multi-byte characters
+			for n > 0 && !utf8.RuneStart(value[n]) {
+				n--
+			}
+		}
+		tags = append(tags, &autoscaling.Tag{
+			Key:               aws.String(fmt.Sprintf("%s:%d", spotzeroSnapshotTag, i)),
+			PropagateAtLaunch: aws.Bool(false),
+			ResourceId:        groupName,
+			ResourceType:      aws.String("auto-scaling-group"),
+			Value:             aws.String(value[:n]),
+		})
+		value = value[n:]
+	}
+	return tags, nil
+}
+
+// checkTagsLimit checks that the group tags, the snapshot tags and spotzero updated tags fit into the group tags limit
+func checkTagsLimit(group *autoscaling.Group, tags []*autoscaling.Tag) error {
+	keys := map[string]bool{spotzeroUpdatedTag: true, spotzeroUpdatedTimeTag: true}
+	for _, t := range tags {
+		keys[aws.StringValue(t.Key)] = true
+	}
+	count := len(keys)
+	for _, t := range group.Tags {
+		if !keys[aws.StringValue(t.Key)] {
+			count++
+		}
+	}
+	if count > maxGroupTags {
+		return fmt.Errorf("autoscaling group needs %d tags with spotzero snapshot (%d tags), exceeding the limit of %d tags per group", count, len(tags), maxGroupTags)
+	}
+	return nil
+}
+
+// snapshotTagKeys returns sorted (by chunk index) keys of the snapshot tags found on the group
+func snapshotTagKeys(tags []*autoscaling.TagDescription) []string {
+	type chunk struct {
+		index int
+		key   string
+	}
+	var chunks []chunk
+	for _, t := range tags {
+		key := aws.StringValue(t.Key)
+		if !strings.HasPrefix(key, spotzeroSnapshotTag+":") {
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimPrefix(key, spotzeroSnapshotTag+":"))
+		if err != nil {
+			continue
+		}
+		chunks = append(chunks, chunk{index, key})
+	}
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].index < chunks[j].index })
+	keys := make([]string, len(chunks))
+	for i, c := range chunks {
+		keys[i] = c.key
+	}
+	return keys
+}
+
+// loadGroupSnapshot restores the snapshot from the `spotzero:snapshot:N` group tags
+func loadGroupSnapshot(group *autoscaling.Group) (*groupSnapshot, error) {
+	values := make(map[string]string, len(group.Tags))
+	for _, t := range group.Tags {
+		values[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+	keys := snapshotTagKeys(group.Tags)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no spotzero snapshot found for the autoscaling group: %v", aws.StringValue(group.AutoScalingGroupARN))
+	}
+	var sb strings.Builder
+	for i, k := range keys {
+		// detect missing chunks
+		if k != fmt.Sprintf("%s:%d", spotzeroSnapshotTag, i) {
+			return nil, fmt.Errorf("incomplete spotzero snapshot, missing tag %s:%d", spotzeroSnapshotTag, i)
+		}
+		sb.WriteString(values[k])
+	}
+	var snapshot groupSnapshot
+	if err := json.Unmarshal([]byte(sb.String()), &snapshot); err != nil {
+		return nil, fmt.Errorf("error parsing spotzero snapshot: %v", err)
+	}
+	if snapshot.LaunchConfigurationName == "" && snapshot.LaunchTemplate == nil && snapshot.MixedInstancesPolicy == nil {
+		return nil, errors.New("empty spotzero snapshot")
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,221 @@
+package autoscaling
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+// generate MixedInstancesPolicy with N overrides
+func testMixedInstancesPolicy(n int) *autoscaling.MixedInstancesPolicy {
+	overrides := make([]*autoscaling.LaunchTemplateOverrides, n)
+	for i := range overrides {
+		overrides[i] = &autoscaling.LaunchTemplateOverrides{
+			InstanceType:     aws.String(fmt.Sprintf("m5.%dxlarge", i+1)),
+			WeightedCapacity: aws.String(fmt.Sprint(4 * (i + 1))),
+		}
+	}
+	return &autoscaling.MixedInstancesPolicy{
+		InstancesDistribution: &autoscaling.InstancesDistribution{
+			OnDemandAllocationStrategy:          aws.String("prioritized"),
+			OnDemandBaseCapacity:                aws.Int64(0),
+			OnDemandPercentageAboveBaseCapacity: aws.Int64(100),
+			SpotAllocationStrategy:              aws.String("lowest-price"),
+			SpotInstancePools:                   aws.Int64(2),
+		},
+		LaunchTemplate: &autoscaling.LaunchTemplate{
+			LaunchTemplateSpecification: &autoscaling.LaunchTemplateSpecification{
+				LaunchTemplateId:   aws.String("lt-0123456789"),
+				LaunchTemplateName: aws.String("test-lt"),
+				Version:            aws.String("$Latest"),
+			},
+			Overrides: overrides,
+		},
+	}
+}
+
+// convert snapshot tags into group tag descriptions
+func testTagDescriptions(tags []*autoscaling.Tag) []*autoscaling.TagDescription {
+	descriptions := make([]*autoscaling.TagDescription, len(tags))
+	for i, t := range tags {
+		descriptions[i] = &autoscaling.TagDescription{Key: t.Key, Value: t.Value}
+	}
+	return descriptions
+}
+
+func Test_groupSnapshot_roundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		group   *autoscaling.Group
+		want    *autoscaling.UpdateAutoScalingGroupInput
+		wantErr bool
+	}{
+		{
+			name: "launch template",
+			group: &autoscaling.Group{
+				AutoScalingGroupName: aws.String("test-asg"),
+				LaunchTemplate: &autoscaling.LaunchTemplateSpecification{
+					LaunchTemplateId:   aws.String("lt-0123456789"),
+					LaunchTemplateName: aws.String("test-lt"),
+					Version:            aws.String("3"),
+				},
+				CapacityRebalance:   aws.Bool(true),
+				MaxInstanceLifetime: aws.Int64(604800),
+			},
+			want: &autoscaling.UpdateAutoScalingGroupInput{
+				AutoScalingGroupName: aws.String("test-asg"),
+				CapacityRebalance:    aws.Bool(true),
+				MaxInstanceLifetime:  aws.Int64(604800),
+				DesiredCapacityType:  aws.String("units"),
+				LaunchTemplate: &autoscaling.LaunchTemplateSpecification{
+					LaunchTemplateId: aws.String("lt-0123456789"),
+					Version:          aws.String("3"),
+				},
+			},
+		},
+		{
+			name: "mixed instances policy split into multiple tags",
+			group: &autoscaling.Group{
+				AutoScalingGroupName: aws.String("test-asg"),
+				DesiredCapacityType:  aws.String("vcpu"),
+				MixedInstancesPolicy: testMixedInstancesPolicy(20),
+			},
+			want: func() *autoscaling.UpdateAutoScalingGroupInput {
+				mip := testMixedInstancesPolicy(20)
+				mip.LaunchTemplate.LaunchTemplateSpecification.LaunchTemplateName = nil
+				return &autoscaling.UpdateAutoScalingGroupInput{
+					AutoScalingGroupName: aws.String("test-asg"),
+					CapacityRebalance:    aws.Bool(false),
+					MaxInstanceLifetime:  aws.Int64(0),
+					DesiredCapacityType:  aws.String("vcpu"),
+					MixedInstancesPolicy: mip,
+				}
+			}(),
+		},
+		{
+			name: "launch configuration",
+			group: &autoscaling.Group{
+				AutoScalingGroupName:    aws.String("test-asg"),
+				LaunchConfigurationName: aws.String("test-lc"),
+			},
+			want: &autoscaling.UpdateAutoScalingGroupInput{
+				AutoScalingGroupName:    aws.String("test-asg"),
+				CapacityRebalance:       aws.Bool(false),
+				LaunchConfigurationName: aws.String("test-lc"),
+				MaxInstanceLifetime:     aws.Int64(0),
+				DesiredCapacityType:     aws.String("units"),
+			},
+		},
+		{
+			name: "launch configuration with multi-byte characters split into multiple tags",
+			group: &autoscaling.Group{
+				AutoScalingGroupName:    aws.String("test-asg"),
+				LaunchConfigurationName: aws.String("test-lc-1" + strings.Repeat("é", 200)),
+			},
+			want: &autoscaling.UpdateAutoScalingGroupInput{
+				AutoScalingGroupName:    aws.String("test-asg"),
+				CapacityRebalance:       aws.Bool(false),
+				LaunchConfigurationName: aws.String("test-lc-1" + strings.Repeat("é", 200)),
+				MaxInstanceLifetime:     aws.Int64(0),
+				DesiredCapacityType:     aws.String("units"),
+			},
+		},
+		{
+			name:    "no launch template",
+			group:   &autoscaling.Group{AutoScalingGroupName: aws.String("test-asg")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot, err := newGroupSnapshot(tt.group)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newGroupSnapshot() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			tags, err := snapshot.tags(tt.group.AutoScalingGroupName)
+			if err != nil {
+				t.Errorf("tags() error = %v", err)
+				return
+			}
+			for _, tag := range tags {
+				if len(*tag.Value) > maxTagValueLength {
+					t.Errorf("tags() value length = %v, want <= %v", len(*tag.Value), maxTagValueLength)
+				}
+				if !utf8.ValidString(*tag.Value) {
+					t.Errorf("tags() value %q is not valid UTF-8", *tag.Value)
+				}
+			}
+			// reverse tags order: loading should not depend on it
+			for i, j := 0, len(tags)-1; i < j; i, j = i+1, j-1 {
+				tags[i], tags[j] = tags[j], tags[i]
+			}
+			loaded, err := loadGroupSnapshot(&autoscaling.Group{Tags: testTagDescriptions(tags)})
+			if err != nil {
+				t.Errorf("loadGroupSnapshot() error = %v", err)
+				return
+			}
+			if got := loaded.createRestoreInput(tt.group.AutoScalingGroupName); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("createRestoreInput() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_loadGroupSnapshot(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []*autoscaling.TagDescription
+	}{
+		{
+			name: "no snapshot tags",
+			tags: createNTagDescriptions(3),
+		},
+		{
+			name: "missing snapshot chunk",
+			tags: []*autoscaling.TagDescription{
+				{Key: aws.String(spotzeroSnapshotTag + ":0"), Value: aws.String(`{"lt":`)},
+				{Key: aws.String(spotzeroSnapshotTag + ":2"), Value: aws.String(`{"id":"lt-1"}}`)},
+			},
+		},
+		{
+			name: "invalid snapshot",
+			tags: []*autoscaling.TagDescription{
+				{Key: aws.String(spotzeroSnapshotTag + ":0"), Value: aws.String(`{"lt":`)},
+			},
+		},
+		{
+			name: "empty snapshot",
+			tags: []*autoscaling.TagDescription{
+				{Key: aws.String(spotzeroSnapshotTag + ":0"), Value: aws.String(`{}`)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadGroupSnapshot(&autoscaling.Group{Tags: tt.tags}); err == nil {
+				t.Errorf("loadGroupSnapshot() expected error")
+			}
+		})
+	}
+}
+
+func Test_groupSnapshot_createRestoreInput_legacy(t *testing.T) {
+	// snapshots taken before spotzero managed Capacity Rebalancing do not change it
+	snapshot := &groupSnapshot{LaunchConfigurationName: "test-lc"}
+	want := &autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName:    aws.String("test-asg"),
+		LaunchConfigurationName: aws.String("test-lc"),
+	}
+	if got := snapshot.createRestoreInput(aws.String("test-asg")); !reflect.DeepEqual(got, want) {
+		t.Errorf("createRestoreInput() = %v, want %v", got, want)
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -16,32 +16,66 @@ import (
 )
 
 const (
-	spotAllocationStrategy = "capacity-optimized"
-	maxAsgTypes            = 20
-	// refresh instances configuration
-	minHealthyPercentage = 90  // 90%
-	instanceWarmup       = 300 // 5 minutes
+	// SpotCapacityOptimized launch Spot instances from the pools with optimal capacity
+	SpotCapacityOptimized = "capacity-optimized"
+	// SpotCapacityOptimizedPrioritized optimize for capacity first, honor the instance type priority (overrides order) on a best-effort basis
+	SpotCapacityOptimizedPrioritized = "capacity-optimized-prioritized"
+	// SpotPriceCapacityOptimized launch Spot instances from the pools with optimal capacity and the lowest price
+	SpotPriceCapacityOptimized = "price-capacity-optimized"
+	// SpotLowestPrice launch Spot instances from the SpotInstancePools lowest priced pools
+	SpotLowestPrice = "lowest-price"
+	// OnDemandPrioritized launch On-Demand instances in the overrides order
+	OnDemandPrioritized = "prioritized"
+	// OnDemandLowestPrice launch the lowest priced On-Demand instances
+	OnDemandLowestPrice = "lowest-price"
+	// default Spot allocation strategy
+	defaultSpotAllocationStrategy = SpotCapacityOptimized
+	// maximum number of Spot pools for the lowest-price strategy
+	maxSpotInstancePools = 20
+	maxAsgTypes          = 20
+	// maximum instance lifetime range in seconds (1 day - 365 days)
+	minInstanceLifetime = 86400
+	maxInstanceLifetime = 31536000
+	// DefaultMinHealthyPercentage default instance refresh minimum healthy percentage (90%)
+	DefaultMinHealthyPercentage = 90
+	// DefaultInstanceWarmup default instance refresh warmup in seconds (5 minutes)
+	DefaultInstanceWarmup = 300
 	// spotzero updated tags
 	spotzeroUpdatedTag     = "spotzero:updated"
 	spotzeroUpdatedTimeTag = "spotzero:updated:time"
 )
 
+// autoscaling groups with launch configuration are updated only with MigrateLaunchConfigurations
+var errLaunchConfiguration = errors.New("autoscaling group with launch configuration is not supported, skipping")
+
 type awsAsgUpdater interface {
 	CreateOrUpdateTagsWithContext(aws.Context, *autoscaling.CreateOrUpdateTagsInput, ...request.Option) (*autoscaling.CreateOrUpdateTagsOutput, error)
 	UpdateAutoScalingGroupWithContext(aws.Context, *autoscaling.UpdateAutoScalingGroupInput, ...request.Option) (*autoscaling.UpdateAutoScalingGroupOutput, error)
 	StartInstanceRefreshWithContext(aws.Context, *autoscaling.StartInstanceRefreshInput, ...request.Option) (*autoscaling.StartInstanceRefreshOutput, error)
+	DeleteTagsWithContext(aws.Context, *autoscaling.DeleteTagsInput, ...request.Option) (*autoscaling.DeleteTagsOutput, error)
+	DescribeLaunchConfigurationsWithContext(aws.Context, *autoscaling.DescribeLaunchConfigurationsInput, ...request.Option) (*autoscaling.DescribeLaunchConfigurationsOutput, error)
+	DescribeInstanceRefreshesWithContext(aws.Context, *autoscaling.DescribeInstanceRefreshesInput, ...request.Option) (*autoscaling.DescribeInstanceRefreshesOutput, error)
+	CancelInstanceRefreshWithContext(aws.Context, *autoscaling.CancelInstanceRefreshInput, ...request.Option) (*autoscaling.CancelInstanceRefreshOutput, error)
+	DescribePoliciesWithContext(aws.Context, *autoscaling.DescribePoliciesInput, ...request.Option) (*autoscaling.DescribePoliciesOutput, error)
+	DescribeScheduledActionsWithContext(aws.Context, *autoscaling.DescribeScheduledActionsInput, ...request.Option) (*autoscaling.DescribeScheduledActionsOutput, error)
 }
 
 type asgUpdaterService struct {
 	asgsvc awsAsgUpdater
 	ec2svc ec2.InstanceDescriber
+	ltsvc  ec2.LaunchTemplateCreator
+	ofsvc  ec2.OfferingDescriber
+	spsvc  ec2.SpotPriceDescriber
 	config Config
+	// interval between instance refresh status checks
+	pollInterval time.Duration
 }
 
 // Updater interface contains methods for updating EC2 Auto Scaling groups
 type Updater interface {
 	CreateUpdateInput(context.Context, *autoscaling.Group) (*autoscaling.UpdateAutoScalingGroupInput, error)
 	Update(context.Context, *autoscaling.Group) error
+	Rollback(ctx context.Context, group *autoscaling.Group, refresh bool) error
 }
 
 // A Config is used for update configuration tuning
@@ -50,55 +84,300 @@ type Config struct {
 	SimilarityConfig ec2.Config
 	// OnDemandBaseCapacity the minimum amount of the Auto Scaling group's capacity that must be fulfilled by On-Demand Instances.
 	// This base portion is provisioned first as your group scales. Defaults to 0 if not specified.
-	// Set the value of OnDemandBaseCapacity in terms of the number of capacity units (VCPU), and not the number of instances.
+	// Set the value of OnDemandBaseCapacity in terms of the number of capacity units (VCPU by default, see Weighting), and not the number of instances.
 	OnDemandBaseCapacity int64
 	// OnDemandPercentageAboveBaseCapacity controls the percentages of On-Demand Instances and Spot Instances for your additional capacity
 	// beyond OnDemandBaseCapacity. Expressed as a number (for example, 20 specifies 20% On-Demand Instances, 80% Spot Instances).
 	// Defaults to 100 if not specified. If set to 100, only On-Demand Instances are provisioned.
 	OnDemandPercentageAboveBaseCapacity int64
+	// SpotAllocationStrategy one of capacity-optimized, capacity-optimized-prioritized, price-capacity-optimized or lowest-price.
+	// Defaults to capacity-optimized if not specified. The overrides order (most similar instance types first) is the priority
+	// for capacity-optimized-prioritized strategy.
+	SpotAllocationStrategy string
+	// SpotInstancePools the number of Spot pools (1-20) to use with lowest-price strategy. Defaults to 2 if not specified.
+	SpotInstancePools int64
+	// OnDemandAllocationStrategy prioritized (overrides order) or lowest-price. Defaults to prioritized if not specified.
+	OnDemandAllocationStrategy string
+	// CapacityRebalance enables Capacity Rebalancing: launch a replacement Spot instance, when a running Spot instance
+	// receives a rebalance recommendation (elevated risk of interruption); false leaves the group setting unchanged.
+	CapacityRebalance bool
+	// MaxInstanceLifetime the maximum number of seconds an instance can be in service (86400-31536000); 0 leaves the group setting unchanged.
+	MaxInstanceLifetime int64
+	// RequireAllZones drops similar instance types, that are not offered in every Availability Zone of the group;
+	// such instance types are only reported otherwise. Instance types not offered in any zone of the group are always dropped.
+	RequireAllZones bool
+	// PriceRanking ranks similar instance types by the hourly price per capacity unit: ondemand (on-demand price in the group region)
+	// or spot (current Spot price in the group Availability Zones); the original instance type stays first.
+	// Instance types are ordered by VCPU closeness to the original instance type if not specified.
+	PriceRanking string
+	// MaxPriceIncrease skips similar instance types with the price per capacity unit more than this percentage above
+	// the price of the original instance type (on-demand price, or Spot price with spot price ranking); 0 does not compare prices.
+	MaxPriceIncrease float64
+	// InstanceRequirements replaces the list of similar instance types with a single override, that describes the attributes
+	// of similar instance types (VCPU and memory ranges, CPU manufacturers, generations, burstable and bare metal instances);
+	// EC2 Auto Scaling picks matching instance types, including new ones. Instances are single capacity units in this mode,
+	// unless vcpu or memory weighting sets the group desired capacity type.
+	InstanceRequirements bool
+	// Weighting instance types weighting strategy: vcpu (default), memory (GiB), normalized (units of the original instance type)
+	// or none (every instance is a single capacity unit). Group min size, max size and desired capacity are converted into
+	// these units; on-demand base capacity is set in these units.
+	Weighting string
+	// ArmImageID arm64 AMI for Graviton instance types: similar arm64 instance types are added to the overrides
+	// with a copy of the group launch template, that uses this AMI.
+	ArmImageID string
+	// ArmLaunchTemplate arm64 launch template (`id-or-name[:version]`) for Graviton instance types: similar arm64 instance types
+	// are added to the overrides with this launch template.
+	ArmLaunchTemplate string
+	// SpotMaxPrice the maximum price per unit hour (VCPU weight) to pay for Spot instances: an absolute price (0.05)
+	// or a percentage of the original instance type on-demand price (80%). Defaults to the on-demand price if not specified.
+	SpotMaxPrice string
+	// MigrateLaunchConfigurations creates a launch template from the launch configuration of the group
+	// and replaces the launch configuration with a MixedInstancesPolicy. Groups with launch configuration are skipped otherwise.
+	MigrateLaunchConfigurations bool
+	// Policy rules override the configuration for matching groups; spotzero tags on the group override the policy rule.
+	Policy *Policy
+	// WaitForRefresh waits for the started instance refresh to complete and fails, if the instance refresh fails or is cancelled.
+	WaitForRefresh bool
+	// RefreshTimeout maximum time to wait for the instance refresh; the instance refresh is cancelled after the timeout.
+	// Defaults to 1 hour if not specified.
+	RefreshTimeout time.Duration
+	// Lambda spotzero runs as AWS Lambda function; RefreshTimeout must fit into the 15 minutes Lambda timeout.
+	Lambda bool
+	// NoRefresh does not start instance refresh after the update; running instances are replaced by natural churn.
+	NoRefresh bool
+	// MinHealthyPercentage the percentage of capacity that must remain healthy during an instance refresh (0-100);
+	// 0 uses the EC2 Auto Scaling default (90%).
+	MinHealthyPercentage int64
+	// InstanceWarmup the number of seconds until a newly launched instance is configured and ready to use;
+	// 0 uses the EC2 Auto Scaling default (the group default instance warmup or health check grace period).
+	InstanceWarmup int64
+	// CheckpointPercentages ascending percentages of replaced instances, after which instance refresh pauses for CheckpointDelay.
+	CheckpointPercentages []int64
+	// CheckpointDelay the number of seconds to wait after a checkpoint.
+	CheckpointDelay int64
+	// SkipMatching skips replacing instances, that already match the desired configuration.
+	SkipMatching bool
+}
+
+// Validate checks the update configuration
+func (c Config) Validate() error {
+	switch c.SpotAllocationStrategy {
+	case "", SpotCapacityOptimized, SpotCapacityOptimizedPrioritized, SpotPriceCapacityOptimized, SpotLowestPrice:
+	default:
+		return fmt.Errorf("invalid spot allocation strategy %q: expected one of %s, %s, %s or %s", c.SpotAllocationStrategy,
+			SpotCapacityOptimized, SpotCapacityOptimizedPrioritized, SpotPriceCapacityOptimized, SpotLowestPrice)
+	}
+	if c.SpotInstancePools != 0 && c.SpotAllocationStrategy != SpotLowestPrice {
+		return fmt.Errorf("spot instance pools are supported only with %s spot allocation strategy", SpotLowestPrice)
+	}
+	if c.SpotInstancePools < 0 || c.SpotInstancePools > maxSpotInstancePools {
+		return fmt.Errorf("invalid spot instance pools %d: expected value between 1 and %d", c.SpotInstancePools, maxSpotInstancePools)
+	}
+	switch c.OnDemandAllocationStrategy {
+	case "", OnDemandPrioritized, OnDemandLowestPrice:
+	default:
+		return fmt.Errorf("invalid on-demand allocation strategy %q: expected %s or %s", c.OnDemandAllocationStrategy, OnDemandPrioritized, OnDemandLowestPrice)
+	}
+	if c.InstanceRequirements && (c.SpotAllocationStrategy == SpotCapacityOptimizedPrioritized || c.OnDemandAllocationStrategy == OnDemandPrioritized) {
+		return errors.New("prioritized allocation strategies are not supported with instance requirements")
+	}
+	if c.ArmImageID != "" && c.ArmLaunchTemplate != "" {
+		return errors.New("arm64 image and arm64 launch template are mutually exclusive")
+	}
+	if c.ArmImageID != "" && !strings.HasPrefix(c.ArmImageID, "ami-") {
+		return fmt.Errorf("invalid arm64 image %q: expected AMI ID", c.ArmImageID)
+	}
+	if c.ArmLaunchTemplate != "" {
+		if _, err := parseLaunchTemplate(c.ArmLaunchTemplate); err != nil {
+			return err
+		}
+	}
+	switch c.Weighting {
+	case "", WeightingVCPU, WeightingMemory, WeightingNormalized, WeightingNone:
+	default:
+		return fmt.Errorf("invalid weighting %q: expected one of %s, %s, %s or %s", c.Weighting, WeightingVCPU, WeightingMemory, WeightingNormalized, WeightingNone)
+	}
+	if err := validateSimilarity(c.SimilarityConfig); err != nil {
+		return err
+	}
+	if err := c.validatePrice(); err != nil {
+		return err
+	}
+	if c.InstanceRequirements && c.Weighting == WeightingNormalized {
+		return errors.New("normalized weighting is not supported with instance requirements")
+	}
+	if c.InstanceRequirements && (c.ArmImageID != "" || c.ArmLaunchTemplate != "") {
+		return errors.New("arm64 instance types are not supported with instance requirements")
+	}
+	if c.InstanceRequirements && c.SimilarityConfig.MinNetworkPerformance > 0 {
+		return errors.New("min network performance is not supported with instance requirements")
+	}
+	if c.SpotMaxPrice != "" {
+		if _, _, err := parseSpotMaxPrice(c.SpotMaxPrice); err != nil {
+			return fmt.Errorf("invalid spot max price %q: %v", c.SpotMaxPrice, err)
+		}
+	}
+	if c.MaxInstanceLifetime != 0 && (c.MaxInstanceLifetime < minInstanceLifetime || c.MaxInstanceLifetime > maxInstanceLifetime) {
+		return fmt.Errorf("invalid max instance lifetime %d: expected number of seconds between %d and %d", c.MaxInstanceLifetime, minInstanceLifetime, maxInstanceLifetime)
+	}
+	if c.MinHealthyPercentage < 0 || c.MinHealthyPercentage > 100 {
+		return fmt.Errorf("invalid min healthy percentage %d: expected value between 0 and 100", c.MinHealthyPercentage)
+	}
+	if c.InstanceWarmup < 0 {
+		return fmt.Errorf("invalid instance warmup %d: expected non-negative number of seconds", c.InstanceWarmup)
+	}
+	if c.CheckpointDelay < 0 {
+		return fmt.Errorf("invalid checkpoint delay %d: expected non-negative number of seconds", c.CheckpointDelay)
+	}
+	if c.CheckpointDelay > 0 && len(c.CheckpointPercentages) == 0 {
+		return errors.New("checkpoint delay requires checkpoint percentages")
+	}
+	if c.Lambda && c.WaitForRefresh && (c.RefreshTimeout <= 0 || c.RefreshTimeout > maxLambdaRefreshTimeout) {
+		return fmt.Errorf("invalid wait timeout %v: expected at most %v in AWS Lambda", c.RefreshTimeout, maxLambdaRefreshTimeout)
+	}
+	var last int64
+	for _, p := range c.CheckpointPercentages {
+		if p <= last || p > 100 {
+			return fmt.Errorf("invalid checkpoint percentages %v: expected ascending values between 1 and 100", c.CheckpointPercentages)
+		}
+		last = p
+	}
+	return nil
 }
 
 // NewUpdater create new Updater
 func NewUpdater(role sts.AssumeRoleInRegion, config Config) Updater {
 	return &asgUpdaterService{
-		asgsvc: autoscaling.New(sts.MustAwsSession(role.Arn, role.ExternalID, role.Region)),
-		ec2svc: ec2.NewInstanceDescriber(role),
-		config: config,
+		asgsvc:       autoscaling.New(sts.MustAwsSession(role.Arn, role.ExternalID, role.Region)),
+		ec2svc:       ec2.NewInstanceDescriber(role),
+		ltsvc:        ec2.NewLaunchTemplateCreator(role),
+		ofsvc:        ec2.NewOfferingDescriber(role),
+		spsvc:        ec2.NewSpotPriceDescriber(role),
+		config:       config,
+		pollInterval: refreshPollInterval,
 	}
 }
 
 // CreateUpdateInput automatically creates a new MixedInstancePolicy for the provided EC2 Auto Scaling group.
 // It returns a properly configured UpdateAutoScalingGroupInput request.
 func (s *asgUpdaterService) CreateUpdateInput(ctx context.Context, group *autoscaling.Group) (*autoscaling.UpdateAutoScalingGroupInput, error) {
-	// get overrides (types, weights) from asg
-	overrides, err := s.createLaunchTemplateOverrides(ctx, group)
+	config, err := s.groupConfig(group)
 	if err != nil {
 		return nil, err
 	}
+	// preview the launch configuration migration without creating the launch template
+	target := group
+	var instance *ec2.InstanceDetails
+	if group.LaunchConfigurationName != nil {
+		if !config.MigrateLaunchConfigurations {
+			return nil, errLaunchConfiguration
+		}
+		target, instance, err = s.previewLaunchConfiguration(ctx, group)
+		if err != nil {
+			return nil, err
+		}
+	}
+	input, _, err := s.createUpdateInput(ctx, target, instance, config, false)
+	return input, err
+}
+
+// groupConfig applies the matching policy rule and spotzero tags to the update configuration
+func (s *asgUpdaterService) groupConfig(group *autoscaling.Group) (Config, error) {
+	config, applied, err := s.config.groupConfig(group)
+	if err != nil {
+		return config, err
+	}
+	if len(applied) > 0 {
+		log.Printf("applying to the autoscaling group %v: %v", aws.StringValue(group.AutoScalingGroupARN), strings.Join(applied, ", "))
+	}
+	return config, nil
+}
+
+// createUpdateInput creates the update request and returns it with the capacity scale applied to the group sizes;
+// the instance details are described from the group launch template, unless known (launch configuration preview);
+// launch templates for other architectures are created only with create set
+func (s *asgUpdaterService) createUpdateInput(ctx context.Context, group *autoscaling.Group, instance *ec2.InstanceDetails, config Config, create bool) (*autoscaling.UpdateAutoScalingGroupInput, float64, error) {
 	// get LT from group
-	template, err := s.getLaunchTemplateSpec(group)
+	template, err := launchTemplateSpec(group)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get launch template: %v", err)
+		return nil, 0, fmt.Errorf("failed to get launch template: %v", err)
+	}
+	if instance == nil {
+		instance, err = s.instanceDetails(ctx, template)
+		if err != nil {
+			return nil, 0, err
+		}
+	} else if err = checkMarketType(instance); err != nil {
+		return nil, 0, err
+	}
+	// similar instance types must be offered in the group Availability Zones;
+	// EC2 Auto Scaling launches only offered instance types matching instance requirements
+	var zones []string
+	if !config.InstanceRequirements {
+		if zones, err = s.groupZones(ctx, group); err != nil {
+			log.Printf("failed to get Availability Zones of the autoscaling group %v, skipping instance type offerings check: %v", aws.StringValue(group.AutoScalingGroupARN), err)
+		}
+	}
+	offerings := s.zoneOfferings(ctx, group, zones, config)
+	prices, err := s.instancePrices(ctx, group, zones, config)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get instance type prices: %v", err)
+	}
+	// get overrides (types, weights or instance requirements) for the original instance type
+	overrides, err := createLaunchTemplateOverrides(instance.TypeName, config, offerings, prices)
+	if err != nil {
+		return nil, 0, err
+	}
+	armTemplate, err := s.armLaunchTemplate(ctx, template, config, create)
+	if err != nil {
+		return nil, 0, err
+	}
+	if armTemplate != nil {
+		armConfig := config
+		armConfig.SimilarityConfig.Architecture = archArm64
+		armOverrides, err := createLaunchTemplateOverrides(instance.TypeName, armConfig, offerings, prices)
+		if err != nil {
+			return nil, 0, err
+		}
+		overrides = mergeArchOverrides(overrides, armOverrides, armTemplate)
+	}
+	distribution := config.instancesDistribution()
+	if config.SpotMaxPrice != "" {
+		capacityType := desiredCapacityType(group, config)
+		if capacityType == nil {
+			capacityType = group.DesiredCapacityType
+		}
+		price, err := spotMaxPrice(group, instance.TypeName, capacityType, overrides, config.SpotMaxPrice)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get spot max price: %v", err)
+		}
+		distribution.SpotMaxPrice = aws.String(price)
 	}
 	// prepare request
 	mixedInstancePolicy := &autoscaling.MixedInstancesPolicy{
-		InstancesDistribution: &autoscaling.InstancesDistribution{
-			OnDemandBaseCapacity:                aws.Int64(s.config.OnDemandBaseCapacity),
-			OnDemandPercentageAboveBaseCapacity: aws.Int64(s.config.OnDemandPercentageAboveBaseCapacity),
-			SpotAllocationStrategy:              aws.String(spotAllocationStrategy),
-		},
+		InstancesDistribution: distribution,
 		LaunchTemplate: &autoscaling.LaunchTemplate{
-			LaunchTemplateSpecification: &autoscaling.LaunchTemplateSpecification{
-				LaunchTemplateId: template.LaunchTemplateId,
-				Version:          template.Version,
-			},
-			Overrides: overrides,
+			LaunchTemplateSpecification: templateReference(template),
+			Overrides:                   overrides,
 		},
 	}
-	return &autoscaling.UpdateAutoScalingGroupInput{
+	input := &autoscaling.UpdateAutoScalingGroupInput{
 		AutoScalingGroupName: group.AutoScalingGroupName,
 		MixedInstancesPolicy: mixedInstancePolicy,
-	}, nil
+	}
+	if config.CapacityRebalance {
+		input.CapacityRebalance = aws.Bool(true)
+	}
+	if config.MaxInstanceLifetime > 0 {
+		input.MaxInstanceLifetime = aws.Int64(config.MaxInstanceLifetime)
+	}
+	// keep group capacity when weighted capacity units differ from the current ones
+	input.DesiredCapacityType = desiredCapacityType(group, config)
+	scale := capacityScale(group, input, instance.TypeName)
+	if scale != 1 {
+		rescaleCapacity(group, input, scale)
+	}
+	return input, scale, nil
 }
 
 // Update automatically updates the provided EC2 Auto Scaling group with an automatically generated MixedInstancePolicy.
@@ -107,43 +386,143 @@ func (s *asgUpdaterService) Update(ctx context.Context, group *autoscaling.Group
 		return nil
 	}
 	log.Printf("updating the autoscaling group %v", *group.AutoScalingGroupARN)
-	// skip ASG with LaunchConfiguration
+	config, err := s.groupConfig(group)
+	if err != nil {
+		return err
+	}
+	// skip ASG with LaunchConfiguration, unless migration to launch template is enabled
+	target := group
 	if group.LaunchConfigurationName != nil {
-		return errors.New("autoscaling group with launch configuration is not supported, skipping")
+		if !config.MigrateLaunchConfigurations {
+			return errLaunchConfiguration
+		}
+		target, err = s.migrateLaunchConfiguration(ctx, group)
+		if err != nil {
+			return err
+		}
 	}
-	input, err := s.CreateUpdateInput(ctx, group)
+	input, scale, err := s.createUpdateInput(ctx, target, nil, config, true)
 	if err != nil {
 		return fmt.Errorf("failed to create autoscaling group update input: %v", err)
 	}
+	if isUpdated(group) {
+		// re-optimize: update only a changed policy and keep the original configuration snapshot
+		if !changed(group, input, config) {
+			log.Printf("autoscaling group %v is already optimized, skipping", *group.AutoScalingGroupARN)
+			return s.updateAutoScalingGroupTags(ctx, group)
+		}
+		err = s.rescaleSnapshot(ctx, group, scale)
+	} else {
+		// keep the original configuration for rollback
+		var migrated *autoscaling.LaunchTemplateSpecification
+		if target != group {
+			migrated = target.LaunchTemplate
+		}
+		err = s.saveSnapshot(ctx, group, migrated, scale)
+	}
+	if err != nil {
+		return err
+	}
 	output, err := s.asgsvc.UpdateAutoScalingGroupWithContext(ctx, input)
 	if err != nil {
 		return fmt.Errorf("error updading autoscaling group: %v", err)
 	}
 	log.Printf("updated autoscaling group: %v", *output)
+	// scaling policies and scheduled actions are described only on update: read-only commands need no extra permissions
+	if scale != 1 {
+		s.warnScalingActions(ctx, group, scale)
+	}
 	// update spotzero tags for the ASG
 	err = s.updateAutoScalingGroupTags(ctx, group)
 	if err != nil {
 		return err
 	}
+	if config.NoRefresh {
+		log.Printf("instance refresh is disabled for the autoscaling group %v; instances are replaced by natural churn", *group.AutoScalingGroupARN)
+		return nil
+	}
 	// refresh instances for the ASG
-	return s.startInstanceRefresh(ctx, group)
+	return s.startInstanceRefresh(ctx, group, config)
 }
 
-func (s *asgUpdaterService) startInstanceRefresh(ctx context.Context, group *autoscaling.Group) error {
+// validateSimilarity checks memory per VCPU tolerance, memory bounds, minimum instance store, network performance and
+// EBS bandwidth of the similarity configuration
+func validateSimilarity(c ec2.Config) error {
+	if c.MemoryPerVCPUTolerance < 0 || c.MemoryPerVCPUTolerance >= 1 {
+		return fmt.Errorf("invalid memory per vcpu tolerance %v: expected value between 0 and 1 (exclusive)", c.MemoryPerVCPUTolerance)
+	}
+	if c.MinMemory < 0 || c.MaxMemory < 0 {
+		return errors.New("invalid memory bounds: expected non-negative number of GiB")
+	}
+	if c.MaxMemory > 0 && c.MaxMemory < c.MinMemory {
+		return fmt.Errorf("invalid memory bounds: max memory %v GiB is less than min memory %v GiB", c.MaxMemory, c.MinMemory)
+	}
+	if c.MinInstanceStore < 0 {
+		return fmt.Errorf("invalid min instance store %v: expected non-negative number of GB", c.MinInstanceStore)
+	}
+	if c.MinNetworkPerformance < 0 {
+		return fmt.Errorf("invalid min network performance %v: expected non-negative number of Gbps", c.MinNetworkPerformance)
+	}
+	if c.MinEBSBandwidth < 0 {
+		return fmt.Errorf("invalid min EBS bandwidth %v: expected non-negative number of Mbps", c.MinEBSBandwidth)
+	}
+	return nil
+}
+
+// instancesDistribution creates instances distribution from the update configuration
+func (c Config) instancesDistribution() *autoscaling.InstancesDistribution {
+	distribution := &autoscaling.InstancesDistribution{
+		OnDemandBaseCapacity:                aws.Int64(c.OnDemandBaseCapacity),
+		OnDemandPercentageAboveBaseCapacity: aws.Int64(c.OnDemandPercentageAboveBaseCapacity),
+		SpotAllocationStrategy:              aws.String(defaultSpotAllocationStrategy),
+	}
+	if c.SpotAllocationStrategy != "" {
+		distribution.SpotAllocationStrategy = aws.String(c.SpotAllocationStrategy)
+	}
+	if c.SpotInstancePools > 0 {
+		distribution.SpotInstancePools = aws.Int64(c.SpotInstancePools)
+	}
+	if c.OnDemandAllocationStrategy != "" {
+		distribution.OnDemandAllocationStrategy = aws.String(c.OnDemandAllocationStrategy)
+	}
+	return distribution
+}
+
+// refreshPreferences creates instance refresh preferences from the update configuration;
+// EC2 Auto Scaling defaults are used for the minimum healthy percentage and instance warmup, that are not set (0)
+func (c Config) refreshPreferences() *autoscaling.RefreshPreferences {
+	preferences := &autoscaling.RefreshPreferences{}
+	if c.InstanceWarmup > 0 {
+		preferences.InstanceWarmup = aws.Int64(c.InstanceWarmup)
+	}
+	if c.MinHealthyPercentage > 0 {
+		preferences.MinHealthyPercentage = aws.Int64(c.MinHealthyPercentage)
+	}
+	if len(c.CheckpointPercentages) > 0 {
+		preferences.CheckpointPercentages = aws.Int64Slice(c.CheckpointPercentages)
+		preferences.CheckpointDelay = aws.Int64(c.CheckpointDelay)
+	}
+	if c.SkipMatching {
+		preferences.SkipMatching = aws.Bool(true)
+	}
+	return preferences
+}
+
+func (s *asgUpdaterService) startInstanceRefresh(ctx context.Context, group *autoscaling.Group, config Config) error {
 	log.Printf("starting instance refresh for the autoscaling group %v", *group.AutoScalingGroupARN)
 	input := &autoscaling.StartInstanceRefreshInput{
 		AutoScalingGroupName: group.AutoScalingGroupName,
-		Preferences: &autoscaling.RefreshPreferences{
-			InstanceWarmup:       aws.Int64(instanceWarmup),
-			MinHealthyPercentage: aws.Int64(minHealthyPercentage),
-		},
+		Preferences:          config.refreshPreferences(),
 	}
 	output, err := s.asgsvc.StartInstanceRefreshWithContext(ctx, input)
 	if err != nil {
 		return fmt.Errorf("error starting instance refresh for the autoscaling group: %v", err)
 	}
 	log.Printf("started instance refresh autoscaling group: %v", *output)
-	return nil
+	if !config.WaitForRefresh {
+		return nil
+	}
+	return s.waitForInstanceRefresh(ctx, group, output.InstanceRefreshId, config.RefreshTimeout)
 }
 
 func (s *asgUpdaterService) updateAutoScalingGroupTags(ctx context.Context, group *autoscaling.Group) error {
@@ -162,7 +541,7 @@ func (s *asgUpdaterService) updateAutoScalingGroupTags(ctx context.Context, grou
 				PropagateAtLaunch: aws.Bool(true),
 				ResourceId:        group.AutoScalingGroupName,
 				ResourceType:      aws.String("auto-scaling-group"),
-				Value:             aws.String(time.Now().String()),
+				Value:             aws.String(time.Now().UTC().Format(time.RFC3339)),
 			},
 		},
 	}
@@ -175,7 +554,7 @@ func (s *asgUpdaterService) updateAutoScalingGroupTags(ctx context.Context, grou
 }
 
 // get LT spec from ASG or MixedInstancePolicy
-func (s *asgUpdaterService) getLaunchTemplateSpec(group *autoscaling.Group) (*autoscaling.LaunchTemplateSpecification, error) {
+func launchTemplateSpec(group *autoscaling.Group) (*autoscaling.LaunchTemplateSpecification, error) {
 	if group == nil {
 		return nil, errors.New("error autoscaling group is nil")
 	}
@@ -188,33 +567,81 @@ func (s *asgUpdaterService) getLaunchTemplateSpec(group *autoscaling.Group) (*au
 	return nil, fmt.Errorf("failed to find launch template attached to the autoscaling group: %v", group.AutoScalingGroupARN)
 }
 
-func (s *asgUpdaterService) createLaunchTemplateOverrides(ctx context.Context, group *autoscaling.Group) ([]*autoscaling.LaunchTemplateOverrides, error) {
-	// get Launch Template from ASG
-	lts, err := s.getLaunchTemplateSpec(group)
+// instanceDetails returns the instance type of the launch template; launch templates requesting Spot instances are not supported
+func (s *asgUpdaterService) instanceDetails(ctx context.Context, lts *autoscaling.LaunchTemplateSpecification) (*ec2.InstanceDetails, error) {
+	// get instance details from LaunchTemplate
+	instance, err := s.ec2svc.GetInstanceDetails(ctx, lts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get launch template: %v", err)
+		return nil, fmt.Errorf("failed to detect instance type for autoscaling group: %v", err)
 	}
-	// get instance details from LaunchTemplate
-	instance, err2 := s.ec2svc.GetInstanceDetails(ctx, lts)
-	if err2 != nil {
-		return nil, fmt.Errorf("failed to detect instance type for autoscaling group: %v", err2)
+	if err = checkMarketType(instance); err != nil {
+		return nil, err
 	}
-	// check if LaunchTemplate is requesting Spot instances in configuration
+	return instance, nil
+}
+
+// checkMarketType checks if LaunchTemplate is requesting Spot instances in configuration
+func checkMarketType(instance *ec2.InstanceDetails) error {
 	if instance.MarketType == ec2.SpotMarketType {
-		return nil, errors.New("incompatible launch template: already requesting for spot instances")
-	}
-	// iterate over good candidates and add them with weights based on #vCPU
-	candidates := ec2.GetSimilarTypes(instance.TypeName, s.config.SimilarityConfig)
-	ltOverrides := make([]*autoscaling.LaunchTemplateOverrides, len(candidates))
-	for i, c := range candidates {
-		// up to maximum number of instance types
-		if i == maxAsgTypes {
+		return errors.New("incompatible launch template: already requesting for spot instances")
+	}
+	return nil
+}
+
+// templateReference returns the launch template ID (or name, if the ID is unknown) and version
+func templateReference(template *autoscaling.LaunchTemplateSpecification) *autoscaling.LaunchTemplateSpecification {
+	if template.LaunchTemplateId != nil {
+		return &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: template.LaunchTemplateId, Version: template.Version}
+	}
+	return &autoscaling.LaunchTemplateSpecification{LaunchTemplateName: template.LaunchTemplateName, Version: template.Version}
+}
+
+// createLaunchTemplateOverrides creates overrides for the original instance type; similar instance types,
+// that are unavailable in the group Availability Zones, are skipped, and prices (if any) rank and filter the rest
+func createLaunchTemplateOverrides(instanceType string, config Config, offerings *zoneOfferings, prices map[string]float64) ([]*autoscaling.LaunchTemplateOverrides, error) {
+	// single override with attributes of similar instance types
+	if config.InstanceRequirements {
+		requirements, err := ec2.GetInstanceRequirements(instanceType, config.SimilarityConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get instance requirements: %v", err)
+		}
+		return []*autoscaling.LaunchTemplateOverrides{{InstanceRequirements: requirements}}, nil
+	}
+	var original ec2.InstanceTypeWeight
+	if config.Weighting == WeightingNormalized || prices != nil {
+		var err error
+		if original, err = ec2.DescribeInstanceType(instanceType); err != nil {
+			return nil, err
+		}
+	}
+	// iterate over good candidates and add them with weights based on the weighting strategy (#vCPU by default);
+	// candidates order is the priority for prioritized allocation strategies
+	candidates := ec2.GetSimilarTypes(instanceType, config.SimilarityConfig)
+	ltOverrides := make([]*autoscaling.LaunchTemplateOverrides, 0, len(candidates))
+	for _, c := range candidates {
+		// up to maximum number of instance types; all candidates are ranked by price
+		if prices == nil && len(ltOverrides) == maxAsgTypes {
 			break
 		}
-		ltOverrides[i] = &autoscaling.LaunchTemplateOverrides{
-			InstanceType:     aws.String(c.InstanceType),
-			WeightedCapacity: aws.String(strconv.Itoa(c.Weight)),
+		if !offerings.available(c.InstanceType) {
+			continue
+		}
+		weight, ok := weightedCapacity(c, original, config.Weighting)
+		if !ok {
+			continue
 		}
+		ltOverrides = append(ltOverrides, &autoscaling.LaunchTemplateOverrides{
+			InstanceType:     aws.String(c.InstanceType),
+			WeightedCapacity: weight,
+		})
+	}
+	if prices != nil {
+		weight, _ := weightedCapacity(original, original, config.Weighting)
+		originalOverride := &autoscaling.LaunchTemplateOverrides{InstanceType: aws.String(instanceType), WeightedCapacity: weight}
+		ltOverrides = priceOverrides(ltOverrides, originalOverride, prices, config)
+	}
+	if len(ltOverrides) > maxAsgTypes {
+		ltOverrides = ltOverrides[:maxAsgTypes]
 	}
 	return ltOverrides, nil
 }
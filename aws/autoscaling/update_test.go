@@ -0,0 +1,317 @@
+package autoscaling
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/doitintl/spotzero/aws/ec2"
+	"github.com/doitintl/spotzero/mocks"
+	ec2mocks "github.com/doitintl/spotzero/mocks/ec2"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "default refresh preferences", config: Config{MinHealthyPercentage: DefaultMinHealthyPercentage, InstanceWarmup: DefaultInstanceWarmup}},
+		{name: "checkpoints", config: Config{CheckpointPercentages: []int64{20, 50, 100}, CheckpointDelay: 600}},
+		{name: "fail: min healthy percentage", config: Config{MinHealthyPercentage: 101}, wantErr: true},
+		{name: "fail: negative warmup", config: Config{InstanceWarmup: -1}, wantErr: true},
+		{name: "fail: unordered checkpoints", config: Config{CheckpointPercentages: []int64{50, 20}}, wantErr: true},
+		{name: "fail: checkpoint above 100", config: Config{CheckpointPercentages: []int64{50, 120}}, wantErr: true},
+		{name: "fail: checkpoint delay without checkpoints", config: Config{CheckpointDelay: 600}, wantErr: true},
+		{name: "wait in lambda", config: Config{Lambda: true, WaitForRefresh: true, RefreshTimeout: 10 * time.Minute}},
+		{name: "long wait timeout without wait in lambda", config: Config{Lambda: true, RefreshTimeout: time.Hour}},
+		{name: "fail: wait timeout above lambda limit", config: Config{Lambda: true, WaitForRefresh: true, RefreshTimeout: time.Hour}, wantErr: true},
+		{name: "fail: default wait timeout in lambda", config: Config{Lambda: true, WaitForRefresh: true}, wantErr: true},
+		{name: "lowest price with spot pools", config: Config{SpotAllocationStrategy: SpotLowestPrice, SpotInstancePools: 4, OnDemandAllocationStrategy: OnDemandLowestPrice}},
+		{name: "capacity optimized prioritized", config: Config{SpotAllocationStrategy: SpotCapacityOptimizedPrioritized, OnDemandAllocationStrategy: OnDemandPrioritized}},
+		{name: "fail: unknown spot allocation strategy", config: Config{SpotAllocationStrategy: "cheapest"}, wantErr: true},
+		{name: "fail: spot pools without lowest price", config: Config{SpotAllocationStrategy: SpotPriceCapacityOptimized, SpotInstancePools: 2}, wantErr: true},
+		{name: "fail: too many spot pools", config: Config{SpotAllocationStrategy: SpotLowestPrice, SpotInstancePools: 21}, wantErr: true},
+		{name: "spot max price percentage", config: Config{SpotMaxPrice: "80%"}},
+		{name: "fail: invalid spot max price", config: Config{SpotMaxPrice: "cheap"}, wantErr: true},
+		{name: "max instance lifetime", config: Config{CapacityRebalance: true, MaxInstanceLifetime: 604800}},
+		{name: "fail: max instance lifetime below 1 day", config: Config{MaxInstanceLifetime: 3600}, wantErr: true},
+		{name: "instance requirements", config: Config{InstanceRequirements: true, SpotAllocationStrategy: SpotPriceCapacityOptimized}},
+		{name: "fail: instance requirements with prioritized strategy", config: Config{InstanceRequirements: true, OnDemandAllocationStrategy: OnDemandPrioritized}, wantErr: true},
+		{name: "arm64 launch template", config: Config{ArmLaunchTemplate: "web-arm64:2"}},
+		{name: "fail: arm64 image and launch template", config: Config{ArmImageID: "ami-1", ArmLaunchTemplate: "web-arm64"}, wantErr: true},
+		{name: "fail: invalid arm64 image", config: Config{ArmImageID: "lt-1"}, wantErr: true},
+		{name: "fail: arm64 with instance requirements", config: Config{ArmImageID: "ami-1", InstanceRequirements: true}, wantErr: true},
+		{name: "memory weighting", config: Config{Weighting: WeightingMemory}},
+		{name: "fail: unknown weighting", config: Config{Weighting: "gpu"}, wantErr: true},
+		{name: "vcpu weighted instance requirements", config: Config{InstanceRequirements: true, Weighting: WeightingVCPU}},
+		{name: "fail: normalized instance requirements", config: Config{InstanceRequirements: true, Weighting: WeightingNormalized}, wantErr: true},
+		{name: "memory constraints", config: Config{SimilarityConfig: ec2.Config{MemoryPerVCPUTolerance: 0.25, MinMemory: 4, MaxMemory: 64}}},
+		{name: "fail: memory per vcpu tolerance", config: Config{SimilarityConfig: ec2.Config{MemoryPerVCPUTolerance: 1}}, wantErr: true},
+		{name: "fail: negative min memory", config: Config{SimilarityConfig: ec2.Config{MinMemory: -1}}, wantErr: true},
+		{name: "fail: max memory less than min memory", config: Config{SimilarityConfig: ec2.Config{MinMemory: 64, MaxMemory: 32}}, wantErr: true},
+		{name: "fail: negative min instance store", config: Config{SimilarityConfig: ec2.Config{MinInstanceStore: -1}}, wantErr: true},
+		{name: "min network performance and EBS bandwidth", config: Config{SimilarityConfig: ec2.Config{MinNetworkPerformance: 10, MinEBSBandwidth: 4750}}},
+		{name: "fail: negative min network performance", config: Config{SimilarityConfig: ec2.Config{MinNetworkPerformance: -1}}, wantErr: true},
+		{name: "fail: negative min EBS bandwidth", config: Config{SimilarityConfig: ec2.Config{MinEBSBandwidth: -1}}, wantErr: true},
+		{name: "fail: min network performance with instance requirements", config: Config{InstanceRequirements: true, SimilarityConfig: ec2.Config{MinNetworkPerformance: 10}}, wantErr: true},
+		{name: "price ranking", config: Config{PriceRanking: PriceRankingSpot, MaxPriceIncrease: 20}},
+		{name: "fail: unknown price ranking", config: Config{PriceRanking: "reserved"}, wantErr: true},
+		{name: "fail: negative max price increase", config: Config{MaxPriceIncrease: -10}, wantErr: true},
+		{name: "fail: price ranking with instance requirements", config: Config{InstanceRequirements: true, PriceRanking: PriceRankingOnDemand}, wantErr: true},
+		{name: "fail: unknown on-demand allocation strategy", config: Config{OnDemandAllocationStrategy: "capacity-optimized"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_refreshPreferences(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   *autoscaling.RefreshPreferences
+	}{
+		{
+			name:   "min healthy percentage and warmup",
+			config: Config{MinHealthyPercentage: 90, InstanceWarmup: 300},
+			want:   &autoscaling.RefreshPreferences{MinHealthyPercentage: aws.Int64(90), InstanceWarmup: aws.Int64(300)},
+		},
+		{
+			name:   "EC2 Auto Scaling defaults",
+			config: Config{},
+			want:   &autoscaling.RefreshPreferences{},
+		},
+		{
+			name:   "checkpoints and skip matching",
+			config: Config{MinHealthyPercentage: 100, InstanceWarmup: 60, CheckpointPercentages: []int64{10, 100}, CheckpointDelay: 900, SkipMatching: true},
+			want: &autoscaling.RefreshPreferences{
+				MinHealthyPercentage:  aws.Int64(100),
+				InstanceWarmup:        aws.Int64(60),
+				CheckpointPercentages: aws.Int64Slice([]int64{10, 100}),
+				CheckpointDelay:       aws.Int64(900),
+				SkipMatching:          aws.Bool(true),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.refreshPreferences(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("refreshPreferences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_instancesDistribution(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   *autoscaling.InstancesDistribution
+	}{
+		{
+			name:   "default strategies",
+			config: Config{OnDemandBaseCapacity: 1, OnDemandPercentageAboveBaseCapacity: 10},
+			want: &autoscaling.InstancesDistribution{
+				OnDemandBaseCapacity:                aws.Int64(1),
+				OnDemandPercentageAboveBaseCapacity: aws.Int64(10),
+				SpotAllocationStrategy:              aws.String(SpotCapacityOptimized),
+			},
+		},
+		{
+			name:   "lowest price",
+			config: Config{SpotAllocationStrategy: SpotLowestPrice, SpotInstancePools: 4, OnDemandAllocationStrategy: OnDemandLowestPrice},
+			want: &autoscaling.InstancesDistribution{
+				OnDemandAllocationStrategy:          aws.String(OnDemandLowestPrice),
+				OnDemandBaseCapacity:                aws.Int64(0),
+				OnDemandPercentageAboveBaseCapacity: aws.Int64(0),
+				SpotAllocationStrategy:              aws.String(SpotLowestPrice),
+				SpotInstancePools:                   aws.Int64(4),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.instancesDistribution(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("instancesDistribution() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_createLaunchTemplateOverrides(t *testing.T) {
+	similarity := ec2.Config{MultiplyFactorUpper: 2, MultiplyFactorLower: 2}
+	prices := ec2.GetOnDemandPrices("us-east-1")
+	tests := []struct {
+		name         string
+		instanceType string
+		config       Config
+		offerings    *zoneOfferings
+		prices       map[string]float64
+		wantTypes    int
+		wantErr      bool
+	}{
+		{name: "similar instance types", instanceType: "m5.4xlarge", config: Config{SimilarityConfig: similarity}, wantTypes: 20},
+		// the 2xlarge types and m5zn.3xlarge are smaller, and m5zn.6xlarge (24 VCPUs) is not a multiple of m5.4xlarge (16 VCPUs)
+		{name: "normalized weights", instanceType: "m5.4xlarge", config: Config{SimilarityConfig: similarity, Weighting: WeightingNormalized}, wantTypes: 13},
+		{name: "unweighted", instanceType: "m5.4xlarge", config: Config{SimilarityConfig: similarity, Weighting: WeightingNone}, wantTypes: 20},
+		{
+			name: "instance types offered in the group zones", instanceType: "m5.4xlarge", config: Config{SimilarityConfig: similarity},
+			offerings: &zoneOfferings{zones: []string{"us-east-1a", "us-east-1b"}, offered: map[string][]string{
+				"m5.4xlarge": {"us-east-1a", "us-east-1b"}, "m5a.4xlarge": {"us-east-1a"}, "m5n.4xlarge": {"us-east-1a", "us-east-1b"},
+			}},
+			wantTypes: 3,
+		},
+		{
+			name: "instance types offered in all group zones", instanceType: "m5.4xlarge", config: Config{SimilarityConfig: similarity},
+			offerings: &zoneOfferings{zones: []string{"us-east-1a", "us-east-1b"}, requireAll: true, offered: map[string][]string{
+				"m5.4xlarge": {"us-east-1a", "us-east-1b"}, "m5a.4xlarge": {"us-east-1a"}, "m5n.4xlarge": {"us-east-1a", "us-east-1b"},
+			}},
+			wantTypes: 2,
+		},
+		{name: "on-demand price ranking", instanceType: "m5.4xlarge", config: Config{SimilarityConfig: similarity, PriceRanking: PriceRankingOnDemand}, prices: prices, wantTypes: 20},
+		{name: "max price increase", instanceType: "m5.4xlarge", config: Config{SimilarityConfig: similarity, MaxPriceIncrease: 1}, prices: prices, wantTypes: 6},
+		{name: "instance requirements", instanceType: "m5.4xlarge", config: Config{SimilarityConfig: similarity, InstanceRequirements: true}},
+		{name: "fail: instance requirements for unknown type", instanceType: "x0.tiny", config: Config{InstanceRequirements: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := createLaunchTemplateOverrides(tt.instanceType, tt.config, tt.offerings, tt.prices)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("createLaunchTemplateOverrides() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.config.InstanceRequirements {
+				if len(got) != 1 || got[0].InstanceRequirements == nil || got[0].InstanceType != nil || got[0].WeightedCapacity != nil {
+					t.Errorf("createLaunchTemplateOverrides() = %v, want single instance requirements override", got)
+				}
+				return
+			}
+			if len(got) != tt.wantTypes {
+				t.Errorf("createLaunchTemplateOverrides() got %d overrides, want %d", len(got), tt.wantTypes)
+			}
+			for i, o := range got {
+				if o == nil || o.InstanceType == nil || (o.WeightedCapacity == nil) != (tt.config.Weighting == WeightingNone) {
+					t.Errorf("createLaunchTemplateOverrides() got incomplete override %v", o)
+				}
+				if i < 2 || tt.config.PriceRanking == "" {
+					continue
+				}
+				// ranked by price per unit after the original instance type
+				previous, _ := unitPrice(got[i-1], tt.prices)
+				if price, ok := unitPrice(o, tt.prices); ok && price < previous {
+					t.Errorf("createLaunchTemplateOverrides() %v is cheaper than %v", *o.InstanceType, *got[i-1].InstanceType)
+				}
+			}
+		})
+	}
+}
+
+func Test_asgUpdaterService_Update(t *testing.T) {
+	tests := []struct {
+		name              string
+		weighting         string
+		noRefresh         bool
+		capacityRebalance bool
+		wantRefresh       bool
+		wantRebalance     *bool
+		wantMinSize       int64
+		wantScaling       bool
+	}{
+		{
+			name:              "update and refresh instances",
+			weighting:         WeightingNone,
+			capacityRebalance: true,
+			wantRefresh:       true,
+			wantRebalance:     aws.Bool(true),
+			wantMinSize:       2,
+		},
+		{
+			name:        "update without instance refresh and keep capacity rebalance",
+			weighting:   WeightingNone,
+			noRefresh:   true,
+			wantMinSize: 2,
+		},
+		{
+			name:        "rescale capacity and check scaling actions",
+			weighting:   WeightingVCPU,
+			noRefresh:   true,
+			wantMinSize: 4,
+			wantScaling: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			template := &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-1"), Version: aws.String("1")}
+			group := &autoscaling.Group{
+				AutoScalingGroupARN:  aws.String("test-asg-arn"),
+				AutoScalingGroupName: aws.String("test-asg"),
+				LaunchTemplate:       template,
+				MinSize:              aws.Int64(2),
+				MaxSize:              aws.Int64(4),
+				DesiredCapacity:      aws.Int64(2),
+			}
+			mockAsgSvc := new(mocks.AwsAsgUpdater)
+			mockEc2Svc := new(ec2mocks.InstanceDescriber)
+			s := &asgUpdaterService{
+				asgsvc: mockAsgSvc,
+				ec2svc: mockEc2Svc,
+				config: Config{Weighting: tt.weighting, NoRefresh: tt.noRefresh, CapacityRebalance: tt.capacityRebalance},
+			}
+			mockEc2Svc.On("GetInstanceDetails", ctx, template).
+				Return(&ec2.InstanceDetails{TypeName: "m5.large", MarketType: ec2.OnDemandMarketType}, nil).Once()
+			// snapshot and updated tags
+			mockAsgSvc.On("CreateOrUpdateTagsWithContext", ctx, mock.AnythingOfType("*autoscaling.CreateOrUpdateTagsInput")).
+				Return(&autoscaling.CreateOrUpdateTagsOutput{}, nil).Twice()
+			var input *autoscaling.UpdateAutoScalingGroupInput
+			mockAsgSvc.On("UpdateAutoScalingGroupWithContext", ctx, mock.AnythingOfType("*autoscaling.UpdateAutoScalingGroupInput")).
+				Run(func(args mock.Arguments) {
+					input = args.Get(1).(*autoscaling.UpdateAutoScalingGroupInput)
+				}).
+				Return(&autoscaling.UpdateAutoScalingGroupOutput{}, nil).Once()
+			if tt.wantScaling {
+				mockAsgSvc.On("DescribePoliciesWithContext", ctx, &autoscaling.DescribePoliciesInput{AutoScalingGroupName: aws.String("test-asg")}).
+					Return(&autoscaling.DescribePoliciesOutput{}, nil).Once()
+				mockAsgSvc.On("DescribeScheduledActionsWithContext", ctx, &autoscaling.DescribeScheduledActionsInput{AutoScalingGroupName: aws.String("test-asg")}).
+					Return(&autoscaling.DescribeScheduledActionsOutput{}, nil).Once()
+			}
+			if tt.wantRefresh {
+				mockAsgSvc.On("StartInstanceRefreshWithContext", ctx, &autoscaling.StartInstanceRefreshInput{
+					AutoScalingGroupName: aws.String("test-asg"),
+					Preferences:          &autoscaling.RefreshPreferences{},
+				}).Return(&autoscaling.StartInstanceRefreshOutput{InstanceRefreshId: aws.String("test-refresh")}, nil).Once()
+			}
+			if err := s.Update(ctx, group); err != nil {
+				t.Errorf("Update() error = %v", err)
+				return
+			}
+			minSize := aws.Int64Value(group.MinSize)
+			if input.MinSize != nil {
+				minSize = *input.MinSize
+			}
+			if minSize != tt.wantMinSize {
+				t.Errorf("Update() min size = %v, want %v", minSize, tt.wantMinSize)
+			}
+			if !reflect.DeepEqual(input.CapacityRebalance, tt.wantRebalance) {
+				t.Errorf("Update() capacity rebalance = %v, want %v", aws.BoolValue(input.CapacityRebalance), aws.BoolValue(tt.wantRebalance))
+			}
+			mockAsgSvc.AssertExpectations(t)
+			mockEc2Svc.AssertExpectations(t)
+			if !tt.wantRefresh {
+				mockAsgSvc.AssertNotCalled(t, "StartInstanceRefreshWithContext", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package autoscaling
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/doitintl/spotzero/aws/ec2"
+)
+
+const (
+	// WeightingVCPU weight instance types by the number of VCPUs (default)
+	WeightingVCPU = "vcpu"
+	// WeightingMemory weight instance types by memory in GiB; instance types with fractional GiB are not used
+	WeightingMemory = "memory"
+	// WeightingNormalized weight instance types in units of the original instance type (by VCPUs);
+	// instance types smaller than the original instance type or not a multiple of it are not used
+	WeightingNormalized = "normalized"
+	// WeightingNone do not weight instance types: every instance is a single capacity unit
+	WeightingNone = "none"
+	// maximum weighted capacity of the MixedInstancesPolicy override
+	maxWeightedCapacity = 999
+)
+
+// weightedCapacity returns the weight of the similar instance type for the weighting strategy (nil for unweighted),
+// and false, if the instance type cannot be weighted (less than a unit, not a whole number of units or more than
+// the maximum weighted capacity)
+func weightedCapacity(candidate, original ec2.InstanceTypeWeight, weighting string) (*string, bool) {
+	var weight int
+	switch weighting {
+	case WeightingNone:
+		return nil, true
+	case WeightingMemory:
+		if candidate.Memory != math.Trunc(candidate.Memory) {
+			return nil, false
+		}
+		weight = int(candidate.Memory)
+	case WeightingNormalized:
+		if original.Weight <= 0 || candidate.Weight%original.Weight != 0 {
+			return nil, false
+		}
+		weight = candidate.Weight / original.Weight
+	default:
+		weight = candidate.Weight
+	}
+	if weight < 1 || weight > maxWeightedCapacity {
+		return nil, false
+	}
+	return aws.String(strconv.Itoa(weight)), true
+}
@@ -0,0 +1,45 @@
+package autoscaling
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/doitintl/spotzero/aws/ec2"
+)
+
+func Test_weightedCapacity(t *testing.T) {
+	original := ec2.InstanceTypeWeight{InstanceType: "m5.xlarge", Weight: 4, Memory: 16}
+	tests := []struct {
+		name      string
+		original  ec2.InstanceTypeWeight
+		candidate ec2.InstanceTypeWeight
+		weighting string
+		want      *string
+		wantOk    bool
+	}{
+		{name: "default vcpu", candidate: ec2.InstanceTypeWeight{InstanceType: "m5.2xlarge", Weight: 8, Memory: 32}, want: aws.String("8"), wantOk: true},
+		{name: "memory", candidate: ec2.InstanceTypeWeight{InstanceType: "m5.2xlarge", Weight: 8, Memory: 32}, weighting: WeightingMemory, want: aws.String("32"), wantOk: true},
+		{name: "normalized", candidate: ec2.InstanceTypeWeight{InstanceType: "m5.2xlarge", Weight: 8, Memory: 32}, weighting: WeightingNormalized, want: aws.String("2"), wantOk: true},
+		{name: "normalized smaller instance type", candidate: ec2.InstanceTypeWeight{InstanceType: "m5.large", Weight: 2, Memory: 8}, weighting: WeightingNormalized},
+		{name: "unweighted", candidate: ec2.InstanceTypeWeight{InstanceType: "m5.large", Weight: 2, Memory: 8}, weighting: WeightingNone, wantOk: true},
+		{name: "memory less than 1 GiB", candidate: ec2.InstanceTypeWeight{InstanceType: "t3.nano", Weight: 2, Memory: 0.5}, weighting: WeightingMemory},
+		{name: "normalized multiple", original: ec2.InstanceTypeWeight{InstanceType: "m5.8xlarge", Weight: 32, Memory: 128},
+			candidate: ec2.InstanceTypeWeight{InstanceType: "m5.16xlarge", Weight: 64, Memory: 256}, weighting: WeightingNormalized, want: aws.String("2"), wantOk: true},
+		{name: "normalized not a multiple", original: ec2.InstanceTypeWeight{InstanceType: "m5.8xlarge", Weight: 32, Memory: 128},
+			candidate: ec2.InstanceTypeWeight{InstanceType: "m5.12xlarge", Weight: 48, Memory: 192}, weighting: WeightingNormalized},
+		{name: "memory with fractional GiB", candidate: ec2.InstanceTypeWeight{InstanceType: "m1.small", Weight: 1, Memory: 1.7}, weighting: WeightingMemory},
+		{name: "memory above maximum weight", candidate: ec2.InstanceTypeWeight{InstanceType: "x1e.32xlarge", Weight: 128, Memory: 3904}, weighting: WeightingMemory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := original
+			if tt.original.InstanceType != "" {
+				original = tt.original
+			}
+			got, ok := weightedCapacity(tt.candidate, original, tt.weighting)
+			if ok != tt.wantOk || aws.StringValue(got) != aws.StringValue(tt.want) || (got == nil) != (tt.want == nil) {
+				t.Errorf("weightedCapacity() = %v, %v, want %v, %v", aws.StringValue(got), ok, aws.StringValue(tt.want), tt.wantOk)
+			}
+		})
+	}
+}
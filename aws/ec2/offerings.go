@@ -0,0 +1,89 @@
+package ec2
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/doitintl/spotzero/aws/sts"
+)
+
+const maxOfferingsReturnedByAPI = 1000
+
+// define interface for used methods only (simplify testing)
+type offeringDescriber interface {
+	DescribeSubnetsWithContext(aws.Context, *ec2.DescribeSubnetsInput, ...request.Option) (*ec2.DescribeSubnetsOutput, error)
+	DescribeInstanceTypeOfferingsPagesWithContext(aws.Context, *ec2.DescribeInstanceTypeOfferingsInput, func(*ec2.DescribeInstanceTypeOfferingsOutput, bool) bool, ...request.Option) error
+}
+
+type offeringService struct {
+	svc offeringDescriber
+}
+
+// OfferingDescriber contains methods for discovering instance types offered in Availability Zones
+type OfferingDescriber interface {
+	SubnetZones(ctx context.Context, subnetIDs []string) ([]string, error)
+	InstanceTypeZones(ctx context.Context, zones []string) (map[string][]string, error)
+}
+
+// NewOfferingDescriber create new OfferingDescriber
+func NewOfferingDescriber(role sts.AssumeRoleInRegion) OfferingDescriber {
+	return &offeringService{
+		svc: ec2.New(sts.MustAwsSession(role.Arn, role.ExternalID, role.Region)),
+	}
+}
+
+// SubnetZones returns a sorted list of Availability Zones of the subnets
+func (s *offeringService) SubnetZones(ctx context.Context, subnetIDs []string) ([]string, error) {
+	output, err := s.svc.DescribeSubnetsWithContext(ctx, &ec2.DescribeSubnetsInput{SubnetIds: aws.StringSlice(subnetIDs)})
+	if err != nil {
+		return nil, fmt.Errorf("error describing subnets: %v", err)
+	}
+	zones := make([]string, 0, len(output.Subnets))
+	for _, subnet := range output.Subnets {
+		zones = append(zones, aws.StringValue(subnet.AvailabilityZone))
+	}
+	return uniqueSorted(zones), nil
+}
+
+// InstanceTypeZones returns instance types offered in the Availability Zones,
+// mapped to a sorted list of the zones offering them
+func (s *offeringService) InstanceTypeZones(ctx context.Context, zones []string) (map[string][]string, error) {
+	input := &ec2.DescribeInstanceTypeOfferingsInput{
+		LocationType: aws.String(ec2.LocationTypeAvailabilityZone),
+		Filters: []*ec2.Filter{
+			{Name: aws.String("location"), Values: aws.StringSlice(zones)},
+		},
+		MaxResults: aws.Int64(maxOfferingsReturnedByAPI),
+	}
+	offered := make(map[string][]string)
+	err := s.svc.DescribeInstanceTypeOfferingsPagesWithContext(ctx, input, func(page *ec2.DescribeInstanceTypeOfferingsOutput, lastPage bool) bool {
+		for _, o := range page.InstanceTypeOfferings {
+			instanceType := aws.StringValue(o.InstanceType)
+			offered[instanceType] = append(offered[instanceType], aws.StringValue(o.Location))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error describing instance type offerings: %v", err)
+	}
+	for instanceType, locations := range offered {
+		offered[instanceType] = uniqueSorted(locations)
+	}
+	return offered, nil
+}
+
+// uniqueSorted sorts the values and removes duplicates in place
+func uniqueSorted(values []string) []string {
+	sort.Strings(values)
+	unique := values[:0]
+	for _, v := range values {
+		if len(unique) == 0 || v != unique[len(unique)-1] {
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,123 @@
+package ec2
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/doitintl/spotzero/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func testOfferings(offerings ...string) *ec2.DescribeInstanceTypeOfferingsOutput {
+	output := &ec2.DescribeInstanceTypeOfferingsOutput{}
+	for i := 0; i < len(offerings); i += 2 {
+		output.InstanceTypeOfferings = append(output.InstanceTypeOfferings, &ec2.InstanceTypeOffering{
+			InstanceType: aws.String(offerings[i]),
+			Location:     aws.String(offerings[i+1]),
+			LocationType: aws.String(ec2.LocationTypeAvailabilityZone),
+		})
+	}
+	return output
+}
+
+func Test_offeringService_SubnetZones(t *testing.T) {
+	tests := []struct {
+		name    string
+		subnets []*ec2.Subnet
+		err     error
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "unique sorted zones",
+			subnets: []*ec2.Subnet{
+				{SubnetId: aws.String("subnet-1"), AvailabilityZone: aws.String("us-east-1b")},
+				{SubnetId: aws.String("subnet-2"), AvailabilityZone: aws.String("us-east-1a")},
+				{SubnetId: aws.String("subnet-3"), AvailabilityZone: aws.String("us-east-1b")},
+			},
+			want: []string{"us-east-1a", "us-east-1b"},
+		},
+		{
+			name:    "fail to describe subnets",
+			err:     errors.New("access denied"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			subnetIDs := []string{"subnet-1", "subnet-2", "subnet-3"}
+			mockSvc := new(mocks.AwsOfferingDescriber)
+			mockSvc.On("DescribeSubnetsWithContext", ctx, &ec2.DescribeSubnetsInput{SubnetIds: aws.StringSlice(subnetIDs)}).
+				Return(&ec2.DescribeSubnetsOutput{Subnets: tt.subnets}, tt.err).Once()
+			s := &offeringService{svc: mockSvc}
+			got, err := s.SubnetZones(ctx, subnetIDs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SubnetZones() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SubnetZones() = %v, want %v", got, tt.want)
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_offeringService_InstanceTypeZones(t *testing.T) {
+	tests := []struct {
+		name    string
+		pages   []*ec2.DescribeInstanceTypeOfferingsOutput
+		err     error
+		want    map[string][]string
+		wantErr bool
+	}{
+		{
+			name: "offerings from all pages",
+			pages: []*ec2.DescribeInstanceTypeOfferingsOutput{
+				testOfferings("m5.large", "us-east-1b", "m5a.large", "us-east-1a"),
+				testOfferings("m5.large", "us-east-1a"),
+			},
+			want: map[string][]string{
+				"m5.large":  {"us-east-1a", "us-east-1b"},
+				"m5a.large": {"us-east-1a"},
+			},
+		},
+		{
+			name:    "fail to describe offerings",
+			err:     errors.New("access denied"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			zones := []string{"us-east-1a", "us-east-1b"}
+			mockSvc := new(mocks.AwsOfferingDescriber)
+			mockSvc.On("DescribeInstanceTypeOfferingsPagesWithContext", ctx, &ec2.DescribeInstanceTypeOfferingsInput{
+				LocationType: aws.String(ec2.LocationTypeAvailabilityZone),
+				Filters:      []*ec2.Filter{{Name: aws.String("location"), Values: aws.StringSlice(zones)}},
+				MaxResults:   aws.Int64(maxOfferingsReturnedByAPI),
+			}, mock.Anything).Run(func(args mock.Arguments) {
+				fn := args.Get(2).(func(*ec2.DescribeInstanceTypeOfferingsOutput, bool) bool)
+				for i, page := range tt.pages {
+					fn(page, i == len(tt.pages)-1)
+				}
+			}).Return(tt.err).Once()
+			s := &offeringService{svc: mockSvc}
+			got, err := s.InstanceTypeZones(ctx, zones)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("InstanceTypeZones() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InstanceTypeZones() = %v, want %v", got, tt.want)
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
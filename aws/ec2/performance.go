@@ -0,0 +1,86 @@
+package ec2
+
+import (
+	"regexp"
+	"strconv"
+
+	ec2instancesinfo "github.com/cristim/ec2-instances-info"
+)
+
+const (
+	// sustained network performance (Gbps) of network optimized (`n`) variants and the largest instance types,
+	// that similar instance types must keep; lower network performance is compared peak to peak
+	preservedNetworkPerformance = 25
+	// maximum EBS bandwidth (Mbps) of EBS optimized (`b`) variants and the largest instance types,
+	// that similar instance types must keep; lower EBS bandwidth is not compared
+	preservedEBSBandwidth = 10000
+)
+
+// network performance in Gbps: `10 Gigabit`, `Up to 25 Gigabit` or `4x 100 Gigabit`
+var networkGigabit = regexp.MustCompile(`^(Up to )?(?:(\d+)x )?(\d+(?:\.\d+)?) Gigabit$`)
+
+// networkPerformance returns the network performance (Gbps) of the instance type and true for burstable (`Up to`)
+// performance, that is the peak value without a known sustained value; 0 for unknown network performance,
+// including the qualitative levels of older instance types (`Low` to `High`)
+func networkPerformance(value string) (float64, bool) {
+	m := networkGigabit.FindStringSubmatch(value)
+	if m == nil {
+		return 0, false
+	}
+	gbps, _ := strconv.ParseFloat(m[3], 64)
+	if m[2] != "" {
+		n, _ := strconv.ParseFloat(m[2], 64)
+		gbps *= n
+	}
+	return gbps, m[1] != ""
+}
+
+// instanceStoreSize returns the total instance store size (GB); 0 without instance store volumes
+func instanceStoreSize(storage *ec2instancesinfo.StorageConfiguration) float64 {
+	if storage == nil {
+		return 0
+	}
+	return float64(storage.Devices) * float64(storage.Size)
+}
+
+// instance store, if the original instance type has it (unless ignored),
+// and at least the minimum instance store size
+func isSimilarStorage(oStore, nStore float64, config Config) bool {
+	if oStore > 0 && nStore == 0 && !config.IgnoreInstanceStore {
+		return false
+	}
+	return nStore >= config.MinInstanceStore
+}
+
+// network performance of the original instance type (unless ignored): sustained network performance of at least
+// preservedNetworkPerformance is kept by sustained network performance only, lower network performance is compared
+// peak to peak (`Up to 10 Gigabit` matches `10 Gigabit`), if both values are known; the minimum network performance is sustained
+func isSimilarNetwork(oNetwork, nNetwork string, config Config) bool {
+	oGbps, oBurst := networkPerformance(oNetwork)
+	nGbps, nBurst := networkPerformance(nNetwork)
+	if !config.IgnoreNetworkPerformance {
+		if !oBurst && oGbps >= preservedNetworkPerformance && (nBurst || nGbps < oGbps) {
+			return false
+		}
+		if oGbps > 0 && nGbps > 0 && nGbps < oGbps {
+			return false
+		}
+	}
+	return config.MinNetworkPerformance <= 0 || (!nBurst && nGbps >= config.MinNetworkPerformance)
+}
+
+// EBS bandwidth of the original instance type, if it is at least preservedEBSBandwidth (unless ignored), and the minimum
+// EBS bandwidth; the dataset has the maximum EBS bandwidth only, which is the peak value for instance types
+// with burstable network performance, so the minimum EBS bandwidth skips them
+func isSimilarEBS(oBandwidth, nBandwidth float32, nBurst bool, config Config) bool {
+	if !config.IgnoreEBSBandwidth && oBandwidth >= preservedEBSBandwidth && nBandwidth < oBandwidth {
+		return false
+	}
+	return config.MinEBSBandwidth <= 0 || (!nBurst && float64(nBandwidth) >= config.MinEBSBandwidth)
+}
+
+// burstableNetwork returns true for burstable (`Up to`) network performance
+func burstableNetwork(value string) bool {
+	_, burst := networkPerformance(value)
+	return burst
+}
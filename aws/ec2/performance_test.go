@@ -0,0 +1,210 @@
+package ec2
+
+import (
+	"testing"
+)
+
+func Test_networkPerformance(t *testing.T) {
+	tests := []struct {
+		value     string
+		want      float64
+		wantBurst bool
+	}{
+		{"10 Gigabit", 10, false},
+		{"Up to 25 Gigabit", 25, true},
+		{"4x 100 Gigabit", 400, false},
+		{"Moderate", 0, false},
+		{"Very Low", 0, false},
+		{"", 0, false},
+		{"Fast", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got, burst := networkPerformance(tt.value); got != tt.want || burst != tt.wantBurst {
+				t.Errorf("networkPerformance() = %v, %v, want %v, %v", got, burst, tt.want, tt.wantBurst)
+			}
+		})
+	}
+}
+
+func Test_isSimilarStorage(t *testing.T) {
+	type args struct {
+		oStore float64
+		nStore float64
+		config Config
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{"no instance store", args{0, 0, Config{}}, true},
+		{"instance store", args{600, 300, Config{}}, true},
+		{"fail: no instance store", args{600, 0, Config{}}, false},
+		{"ignore instance store", args{600, 0, Config{IgnoreInstanceStore: true}}, true},
+		{"minimum instance store", args{0, 600, Config{MinInstanceStore: 500}}, true},
+		{"fail: below minimum instance store", args{600, 300, Config{MinInstanceStore: 500}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSimilarStorage(tt.args.oStore, tt.args.nStore, tt.args.config); got != tt.want {
+				t.Errorf("isSimilarStorage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isSimilarNetwork(t *testing.T) {
+	type args struct {
+		oNetwork string
+		nNetwork string
+		config   Config
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{"same network performance", args{"25 Gigabit", "25 Gigabit", Config{}}, true},
+		{"higher network performance", args{"10 Gigabit", "25 Gigabit", Config{}}, true},
+		{"fail: lower network performance", args{"25 Gigabit", "20 Gigabit", Config{}}, false},
+		{"fail: burstable network performance", args{"25 Gigabit", "Up to 25 Gigabit", Config{}}, false},
+		{"fail: half network performance", args{"50 Gigabit", "25 Gigabit", Config{MultiplyFactorLower: 2}}, false},
+		{"peak network performance", args{"10 Gigabit", "Up to 10 Gigabit", Config{}}, true},
+		{"fail: lower peak network performance", args{"Up to 25 Gigabit", "Up to 10 Gigabit", Config{}}, false},
+		{"legacy network performance is not compared", args{"Up to 10 Gigabit", "High", Config{}}, true},
+		{"fail: legacy below preserved network performance", args{"25 Gigabit", "High", Config{}}, false},
+		{"legacy network performance", args{"Moderate", "High", Config{}}, true},
+		{"legacy original network performance", args{"Moderate", "Up to 10 Gigabit", Config{}}, true},
+		{"ignore network performance", args{"25 Gigabit", "Up to 10 Gigabit", Config{IgnoreNetworkPerformance: true}}, true},
+		{"minimum network performance", args{"Up to 10 Gigabit", "10 Gigabit", Config{MinNetworkPerformance: 10}}, true},
+		{"fail: burstable below minimum network performance", args{"Up to 10 Gigabit", "Up to 25 Gigabit", Config{MinNetworkPerformance: 10}}, false},
+		{"fail: ignore network performance with minimum", args{"25 Gigabit", "10 Gigabit", Config{IgnoreNetworkPerformance: true, MinNetworkPerformance: 20}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSimilarNetwork(tt.args.oNetwork, tt.args.nNetwork, tt.args.config); got != tt.want {
+				t.Errorf("isSimilarNetwork() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isSimilarEBS(t *testing.T) {
+	type args struct {
+		oBandwidth float32
+		nBandwidth float32
+		nBurst     bool
+		config     Config
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{"same EBS bandwidth", args{10000, 10000, false, Config{}}, true},
+		{"lower EBS bandwidth below preserved tier", args{6800, 4750, true, Config{}}, true},
+		{"fail: EBS optimized variant", args{10000, 9500, false, Config{}}, false},
+		{"fail: lower EBS bandwidth of the largest size", args{19000, 13570, false, Config{MultiplyFactorLower: 2}}, false},
+		{"ignore EBS bandwidth", args{10000, 4750, true, Config{IgnoreEBSBandwidth: true}}, true},
+		{"minimum EBS bandwidth", args{4750, 4750, false, Config{MinEBSBandwidth: 4750}}, true},
+		{"fail: burstable minimum EBS bandwidth", args{4750, 4750, true, Config{MinEBSBandwidth: 4750}}, false},
+		{"fail: below minimum EBS bandwidth", args{4750, 2000, false, Config{MinEBSBandwidth: 4750}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSimilarEBS(tt.args.oBandwidth, tt.args.nBandwidth, tt.args.nBurst, tt.args.config); got != tt.want {
+				t.Errorf("isSimilarEBS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_GetSimilarTypes_performance(t *testing.T) {
+	tests := []struct {
+		name         string
+		instanceType string
+		config       Config
+		wantTypes    []string
+		skipTypes    []string
+	}{
+		{
+			name:         "sustained network performance",
+			instanceType: "m5n.8xlarge",
+			config:       Config{MultiplyFactorUpper: 2, MultiplyFactorLower: 2},
+			wantTypes:    []string{"m5n.12xlarge", "m5dn.8xlarge"},
+			skipTypes:    []string{"m5n.4xlarge", "m5n.2xlarge"},
+		},
+		{
+			name:         "sustained network performance with any family",
+			instanceType: "m5n.8xlarge",
+			config:       Config{IgnoreFamily: true, MultiplyFactorUpper: 2, MultiplyFactorLower: 2},
+			wantTypes:    []string{"r5n.8xlarge"},
+			skipTypes:    []string{"m5n.4xlarge", "m5.16xlarge", "m5.8xlarge"},
+		},
+		{
+			name:         "network optimized",
+			instanceType: "c5n.9xlarge",
+			config:       Config{IgnoreFamily: true, MultiplyFactorUpper: 2, MultiplyFactorLower: 2},
+			wantTypes:    []string{"c5n.18xlarge"},
+			skipTypes:    []string{"c5.18xlarge", "m5.16xlarge"},
+		},
+		{
+			name:         "ignore network performance and EBS bandwidth",
+			instanceType: "c5n.9xlarge",
+			config:       Config{IgnoreFamily: true, MultiplyFactorUpper: 2, MultiplyFactorLower: 2, IgnoreNetworkPerformance: true, IgnoreEBSBandwidth: true},
+			wantTypes:    []string{"c5.18xlarge", "m5.16xlarge"},
+		},
+		{
+			name:         "previous generation with default flags",
+			instanceType: "m4.large",
+			config:       Config{IgnoreGeneration: true, MultiplyFactorUpper: 2, MultiplyFactorLower: 2},
+			wantTypes:    []string{"m5.large", "m5a.large", "m5.xlarge"},
+		},
+		{
+			name:         "sustained 10 Gigabit with default flags",
+			instanceType: "m5.8xlarge",
+			config:       Config{IgnoreGeneration: true, MultiplyFactorUpper: 2, MultiplyFactorLower: 2},
+			wantTypes:    []string{"m5a.8xlarge", "m5.4xlarge", "m5a.4xlarge", "m5d.8xlarge", "m4.4xlarge"},
+		},
+		{
+			name:         "EBS optimized variant",
+			instanceType: "r5b.4xlarge",
+			config:       Config{MultiplyFactorUpper: 2, MultiplyFactorLower: 2},
+			wantTypes:    []string{"r5b.8xlarge"},
+			skipTypes:    []string{"r5.4xlarge", "r5.8xlarge"},
+		},
+		{
+			name:         "minimum network performance",
+			instanceType: "m5.4xlarge",
+			config:       Config{MultiplyFactorUpper: 2, MultiplyFactorLower: 2, MinNetworkPerformance: 10},
+			wantTypes:    []string{"m5.8xlarge"},
+			skipTypes:    []string{"m5n.4xlarge", "m4.4xlarge"},
+		},
+		{
+			name:         "minimum EBS bandwidth",
+			instanceType: "m5.4xlarge",
+			config:       Config{MultiplyFactorUpper: 2, MultiplyFactorLower: 2, MinEBSBandwidth: 4750},
+			wantTypes:    []string{"m5.8xlarge"},
+			skipTypes:    []string{"m4.4xlarge", "m5.2xlarge"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(map[string]bool)
+			for _, it := range GetSimilarTypes(tt.instanceType, tt.config) {
+				got[it.InstanceType] = true
+			}
+			for _, it := range tt.wantTypes {
+				if !got[it] {
+					t.Errorf("GetSimilarTypes() missing %v", it)
+				}
+			}
+			for _, it := range tt.skipTypes {
+				if got[it] {
+					t.Errorf("GetSimilarTypes() got %v, want it skipped", it)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package ec2
+
+import "fmt"
+
+// GetOnDemandPrice returns the hourly Linux on-demand price of the EC2 instance type in the AWS Region
+func GetOnDemandPrice(instanceType, region string) (float64, error) {
+	for _, it := range *ec2data {
+		if it.InstanceType != instanceType {
+			continue
+		}
+		price := it.Pricing[region].Linux.OnDemand
+		if price <= 0 {
+			return 0, fmt.Errorf("no on-demand price for instance type %v in region %v", instanceType, region)
+		}
+		return price, nil
+	}
+	return 0, fmt.Errorf("unknown instance type %v", instanceType)
+}
+
+// GetOnDemandPrices returns hourly Linux on-demand prices of all EC2 instance types offered in the AWS Region
+func GetOnDemandPrices(region string) map[string]float64 {
+	prices := make(map[string]float64)
+	for _, it := range *ec2data {
+		if price := it.Pricing[region].Linux.OnDemand; price > 0 {
+			prices[it.InstanceType] = price
+		}
+	}
+	return prices
+}
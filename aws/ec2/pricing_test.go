@@ -0,0 +1,50 @@
+package ec2
+
+import "testing"
+
+func TestGetOnDemandPrice(t *testing.T) {
+	tests := []struct {
+		name         string
+		instanceType string
+		region       string
+		wantErr      bool
+	}{
+		{name: "known instance type", instanceType: "m5.large", region: "us-east-1"},
+		{name: "fail: unknown region", instanceType: "m5.large", region: "moon-east-1", wantErr: true},
+		{name: "fail: unknown instance type", instanceType: "x0.tiny", region: "us-east-1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetOnDemandPrice(tt.instanceType, tt.region)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetOnDemandPrice() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got <= 0 {
+				t.Errorf("GetOnDemandPrice() = %v, want positive price", got)
+			}
+		})
+	}
+}
+
+func TestGetOnDemandPrices(t *testing.T) {
+	tests := []struct {
+		name      string
+		region    string
+		wantEmpty bool
+	}{
+		{name: "known region", region: "us-east-1"},
+		{name: "unknown region", region: "moon-east-1", wantEmpty: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GetOnDemandPrices(tt.region)
+			if (len(got) == 0) != tt.wantEmpty {
+				t.Errorf("GetOnDemandPrices() got %d prices, want empty %v", len(got), tt.wantEmpty)
+			}
+			if price, err := GetOnDemandPrice("m5.large", tt.region); err == nil && got["m5.large"] != price {
+				t.Errorf("GetOnDemandPrices() m5.large = %v, want %v", got["m5.large"], price)
+			}
+		})
+	}
+}
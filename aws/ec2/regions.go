@@ -0,0 +1,49 @@
+package ec2
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/doitintl/spotzero/aws/sts"
+)
+
+// define interface for used methods only (simplify testing)
+type regionDescriber interface {
+	DescribeRegionsWithContext(aws.Context, *ec2.DescribeRegionsInput, ...request.Option) (*ec2.DescribeRegionsOutput, error)
+}
+
+type regionService struct {
+	svc regionDescriber
+}
+
+// RegionLister contains methods for discovering AWS Regions
+type RegionLister interface {
+	ListRegions(ctx context.Context) ([]string, error)
+}
+
+// NewRegionLister create new RegionLister
+func NewRegionLister(role sts.AssumeRoleInRegion) RegionLister {
+	return &regionService{
+		svc: ec2.New(sts.MustAwsSession(role.Arn, role.ExternalID, role.Region)),
+	}
+}
+
+// ListRegions list AWS Regions enabled for the account.
+// It returns a sorted list of AWS Region names.
+func (s *regionService) ListRegions(ctx context.Context) ([]string, error) {
+	// only regions enabled for the account are returned by default
+	output, err := s.svc.DescribeRegionsWithContext(ctx, &ec2.DescribeRegionsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("error describing regions: %v", err)
+	}
+	regions := make([]string, len(output.Regions))
+	for i, r := range output.Regions {
+		regions[i] = aws.StringValue(r.RegionName)
+	}
+	sort.Strings(regions)
+	return regions, nil
+}
@@ -0,0 +1,58 @@
+package ec2
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/doitintl/spotzero/mocks"
+)
+
+func Test_regionService_ListRegions(t *testing.T) {
+	tests := []struct {
+		name    string
+		regions []string
+		err     error
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "sorted regions",
+			regions: []string{"us-west-2", "eu-west-1", "us-east-1"},
+			want:    []string{"eu-west-1", "us-east-1", "us-west-2"},
+		},
+		{
+			name: "no regions",
+			want: []string{},
+		},
+		{
+			name:    "fail to describe regions",
+			err:     errors.New("access denied"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			output := &ec2.DescribeRegionsOutput{}
+			for _, r := range tt.regions {
+				output.Regions = append(output.Regions, &ec2.Region{RegionName: aws.String(r)})
+			}
+			mockSvc := new(mocks.AwsRegionDescriber)
+			mockSvc.On("DescribeRegionsWithContext", ctx, &ec2.DescribeRegionsInput{}).Return(output, tt.err).Once()
+			s := &regionService{svc: mockSvc}
+			got, err := s.ListRegions(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ListRegions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListRegions() = %v, want %v", got, tt.want)
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
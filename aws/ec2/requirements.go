@@ -0,0 +1,112 @@
+package ec2
+
+import (
+	"fmt"
+	"log"
+	gomath "math"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/doitintl/spotzero/internal/math"
+)
+
+const (
+	archArm64  = "arm64"
+	burstable  = "t"
+	currentGen = "current"
+	mibInGiB   = 1024
+	// memory per VCPU tolerance, that keeps the instance family without --memory-per-vcpu-tolerance
+	defaultMemoryPerVCPUTolerance = 0.25
+)
+
+// GetInstanceRequirements describes EC2 instance types, that are similar to the specified EC2 instance type, with attributes:
+// VCPU and memory ranges (using the Config multiply factors), CPU manufacturers (by architecture), instance generations,
+// burstable and bare metal instances and accelerators. Memory per VCPU is kept within the tolerance (25%, if not set,
+// to include instance families with nearly the same memory per VCPU), unless instance family is ignored without tolerance. Memory bounds narrow the memory range. Instance store volumes are required, if the original
+// instance type has them or the minimum instance store size is set. The minimum baseline EBS bandwidth is the minimum EBS bandwidth or
+// the preserved EBS bandwidth of the original instance type; preserved network performance is logged as not supported.
+// It returns InstanceRequirements for the MixedInstancesPolicy override, that lets EC2 Auto Scaling pick new instance types.
+func GetInstanceRequirements(instanceType string, config Config) (*autoscaling.InstanceRequirements, error) {
+	for _, it := range *ec2data {
+		if it.InstanceType != instanceType {
+			continue
+		}
+		if it.VCPU <= 0 || it.Memory <= 0 {
+			return nil, fmt.Errorf("unknown VCPU and memory for instance type %v", instanceType)
+		}
+		requirements := &autoscaling.InstanceRequirements{
+			VCpuCount: &autoscaling.VCpuCountRequest{
+				Min: aws.Int64(int64(math.MaxInt(it.VCPU/math.MaxInt(config.MultiplyFactorLower, 1), 1))),
+				Max: aws.Int64(int64(it.VCPU * math.MaxInt(config.MultiplyFactorUpper, 1))),
+			},
+			MemoryMiB: &autoscaling.MemoryMiBRequest{
+				Min: aws.Int64(int64(it.Memory*mibInGiB) / int64(math.MaxInt(config.MultiplyFactorLower, 1))),
+				Max: aws.Int64(int64(it.Memory*mibInGiB) * int64(math.MaxInt(config.MultiplyFactorUpper, 1))),
+			},
+			CpuManufacturers:     cpuManufacturers(it.Arch),
+			BurstablePerformance: aws.String(autoscaling.BurstablePerformanceExcluded),
+			BareMetal:            aws.String(autoscaling.BareMetalExcluded),
+			InstanceGenerations:  aws.StringSlice([]string{autoscaling.InstanceGenerationCurrent}),
+			AcceleratorCount:     &autoscaling.AcceleratorCountRequest{Max: aws.Int64(0)},
+		}
+		memoryPerVCPU := float64(it.Memory) / float64(it.VCPU)
+		t := config.MemoryPerVCPUTolerance
+		if t <= 0 && !config.IgnoreFamily {
+			t = defaultMemoryPerVCPUTolerance
+		}
+		if t > 0 {
+			requirements.MemoryGiBPerVCpu = &autoscaling.MemoryGiBPerVCpuRequest{Min: aws.Float64(memoryPerVCPU * (1 - t)), Max: aws.Float64(memoryPerVCPU * (1 + t))}
+		}
+		if minMiB := int64(config.MinMemory * mibInGiB); minMiB > aws.Int64Value(requirements.MemoryMiB.Min) {
+			requirements.MemoryMiB.Min = aws.Int64(minMiB)
+		}
+		if maxMiB := int64(config.MaxMemory * mibInGiB); maxMiB > 0 && maxMiB < aws.Int64Value(requirements.MemoryMiB.Max) {
+			requirements.MemoryMiB.Max = aws.Int64(maxMiB)
+		}
+		if instanceStoreSize(it.Storage) > 0 && !config.IgnoreInstanceStore {
+			requirements.LocalStorage = aws.String(autoscaling.LocalStorageRequired)
+		}
+		if config.MinInstanceStore > 0 {
+			requirements.LocalStorage = aws.String(autoscaling.LocalStorageRequired)
+			requirements.TotalLocalStorageGB = &autoscaling.TotalLocalStorageGBRequest{Min: aws.Float64(config.MinInstanceStore)}
+		}
+		minEBS := config.MinEBSBandwidth
+		if it.EBSMaxBandwidth >= preservedEBSBandwidth && !config.IgnoreEBSBandwidth {
+			minEBS = gomath.Max(minEBS, float64(it.EBSMaxBandwidth))
+		}
+		if minEBS > 0 {
+			requirements.BaselineEbsBandwidthMbps = &autoscaling.BaselineEbsBandwidthMbpsRequest{Min: aws.Int64(int64(gomath.Ceil(minEBS)))}
+		}
+		// instance requirements have no network bandwidth attribute in the AWS SDK version used
+		if gbps, burst := networkPerformance(it.NetworkPerformance); !burst && gbps >= preservedNetworkPerformance && !config.IgnoreNetworkPerformance {
+			log.Printf("warning: network performance %v of instance type %v is not preserved with instance requirements", it.NetworkPerformance, instanceType)
+		}
+		if strings.HasPrefix(instanceType, burstable) {
+			requirements.BurstablePerformance = aws.String(autoscaling.BurstablePerformanceIncluded)
+		}
+		if strings.HasSuffix(instanceType, "."+metal) {
+			requirements.BareMetal = aws.String(autoscaling.BareMetalRequired)
+		}
+		if it.Generation != currentGen || config.IgnoreGeneration {
+			requirements.InstanceGenerations = aws.StringSlice([]string{autoscaling.InstanceGenerationCurrent, autoscaling.InstanceGenerationPrevious})
+		}
+		// if using GPU at least the same number of GPUs
+		if it.GPU > 0 {
+			requirements.AcceleratorCount = &autoscaling.AcceleratorCountRequest{Min: aws.Int64(int64(it.GPU))}
+			requirements.AcceleratorTypes = aws.StringSlice([]string{autoscaling.AcceleratorTypeGpu})
+		}
+		return requirements, nil
+	}
+	return nil, fmt.Errorf("unknown instance type %v", instanceType)
+}
+
+// cpuManufacturers returns CPU manufacturers, that support all the instance type architectures
+func cpuManufacturers(arch []string) []*string {
+	for _, a := range arch {
+		if a == archArm64 {
+			return aws.StringSlice([]string{autoscaling.CpuManufacturerAmazonWebServices})
+		}
+	}
+	return aws.StringSlice([]string{autoscaling.CpuManufacturerIntel, autoscaling.CpuManufacturerAmd})
+}
@@ -0,0 +1,165 @@
+package ec2
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+//nolint:funlen
+func TestGetInstanceRequirements(t *testing.T) {
+	config := Config{MultiplyFactorUpper: 2, MultiplyFactorLower: 2}
+	tests := []struct {
+		name         string
+		instanceType string
+		config       Config
+		want         *autoscaling.InstanceRequirements
+		wantErr      bool
+	}{
+		{
+			name:         "general purpose x86",
+			instanceType: "m5.4xlarge",
+			config:       config,
+			want: &autoscaling.InstanceRequirements{
+				VCpuCount:            &autoscaling.VCpuCountRequest{Min: aws.Int64(8), Max: aws.Int64(32)},
+				MemoryMiB:            &autoscaling.MemoryMiBRequest{Min: aws.Int64(32768), Max: aws.Int64(131072)},
+				MemoryGiBPerVCpu:     &autoscaling.MemoryGiBPerVCpuRequest{Min: aws.Float64(3), Max: aws.Float64(5)},
+				CpuManufacturers:     aws.StringSlice([]string{"intel", "amd"}),
+				BurstablePerformance: aws.String("excluded"),
+				BareMetal:            aws.String("excluded"),
+				InstanceGenerations:  aws.StringSlice([]string{"current"}),
+				AcceleratorCount:     &autoscaling.AcceleratorCountRequest{Max: aws.Int64(0)},
+			},
+		},
+		{
+			name:         "memory per VCPU tolerance and memory bounds",
+			instanceType: "m5.4xlarge",
+			config:       Config{IgnoreFamily: true, MultiplyFactorUpper: 2, MultiplyFactorLower: 2, MemoryPerVCPUTolerance: 0.5, MinMemory: 48, MaxMemory: 96},
+			want: &autoscaling.InstanceRequirements{
+				VCpuCount:            &autoscaling.VCpuCountRequest{Min: aws.Int64(8), Max: aws.Int64(32)},
+				MemoryMiB:            &autoscaling.MemoryMiBRequest{Min: aws.Int64(49152), Max: aws.Int64(98304)},
+				MemoryGiBPerVCpu:     &autoscaling.MemoryGiBPerVCpuRequest{Min: aws.Float64(2), Max: aws.Float64(6)},
+				CpuManufacturers:     aws.StringSlice([]string{"intel", "amd"}),
+				BurstablePerformance: aws.String("excluded"),
+				BareMetal:            aws.String("excluded"),
+				InstanceGenerations:  aws.StringSlice([]string{"current"}),
+				AcceleratorCount:     &autoscaling.AcceleratorCountRequest{Max: aws.Int64(0)},
+			},
+		},
+		{
+			name:         "burstable graviton ignore family and generation",
+			instanceType: "t4g.large",
+			config:       Config{IgnoreFamily: true, IgnoreGeneration: true, MultiplyFactorUpper: 1, MultiplyFactorLower: 1},
+			want: &autoscaling.InstanceRequirements{
+				VCpuCount:            &autoscaling.VCpuCountRequest{Min: aws.Int64(2), Max: aws.Int64(2)},
+				MemoryMiB:            &autoscaling.MemoryMiBRequest{Min: aws.Int64(8192), Max: aws.Int64(8192)},
+				CpuManufacturers:     aws.StringSlice([]string{"amazon-web-services"}),
+				BurstablePerformance: aws.String("included"),
+				BareMetal:            aws.String("excluded"),
+				InstanceGenerations:  aws.StringSlice([]string{"current", "previous"}),
+				AcceleratorCount:     &autoscaling.AcceleratorCountRequest{Max: aws.Int64(0)},
+			},
+		},
+		{
+			name:         "minimum instance store",
+			instanceType: "m5.4xlarge",
+			config:       Config{MultiplyFactorUpper: 1, MultiplyFactorLower: 1, MinInstanceStore: 100},
+			want: &autoscaling.InstanceRequirements{
+				VCpuCount:            &autoscaling.VCpuCountRequest{Min: aws.Int64(16), Max: aws.Int64(16)},
+				MemoryMiB:            &autoscaling.MemoryMiBRequest{Min: aws.Int64(65536), Max: aws.Int64(65536)},
+				MemoryGiBPerVCpu:     &autoscaling.MemoryGiBPerVCpuRequest{Min: aws.Float64(3), Max: aws.Float64(5)},
+				CpuManufacturers:     aws.StringSlice([]string{"intel", "amd"}),
+				BurstablePerformance: aws.String("excluded"),
+				BareMetal:            aws.String("excluded"),
+				LocalStorage:         aws.String("required"),
+				TotalLocalStorageGB:  &autoscaling.TotalLocalStorageGBRequest{Min: aws.Float64(100)},
+				InstanceGenerations:  aws.StringSlice([]string{"current"}),
+				AcceleratorCount:     &autoscaling.AcceleratorCountRequest{Max: aws.Int64(0)},
+			},
+		},
+		{
+			name:         "ignore preserved EBS bandwidth",
+			instanceType: "r5b.4xlarge",
+			config:       Config{MultiplyFactorUpper: 1, MultiplyFactorLower: 1, IgnoreEBSBandwidth: true},
+			want: &autoscaling.InstanceRequirements{
+				VCpuCount:            &autoscaling.VCpuCountRequest{Min: aws.Int64(16), Max: aws.Int64(16)},
+				MemoryMiB:            &autoscaling.MemoryMiBRequest{Min: aws.Int64(131072), Max: aws.Int64(131072)},
+				MemoryGiBPerVCpu:     &autoscaling.MemoryGiBPerVCpuRequest{Min: aws.Float64(6), Max: aws.Float64(10)},
+				CpuManufacturers:     aws.StringSlice([]string{"intel", "amd"}),
+				BurstablePerformance: aws.String("excluded"),
+				BareMetal:            aws.String("excluded"),
+				InstanceGenerations:  aws.StringSlice([]string{"current"}),
+				AcceleratorCount:     &autoscaling.AcceleratorCountRequest{Max: aws.Int64(0)},
+			},
+		},
+		{
+			name:         "preserved EBS bandwidth",
+			instanceType: "r5b.4xlarge",
+			config:       Config{MultiplyFactorUpper: 1, MultiplyFactorLower: 1},
+			want: &autoscaling.InstanceRequirements{
+				VCpuCount:                &autoscaling.VCpuCountRequest{Min: aws.Int64(16), Max: aws.Int64(16)},
+				MemoryMiB:                &autoscaling.MemoryMiBRequest{Min: aws.Int64(131072), Max: aws.Int64(131072)},
+				MemoryGiBPerVCpu:         &autoscaling.MemoryGiBPerVCpuRequest{Min: aws.Float64(6), Max: aws.Float64(10)},
+				CpuManufacturers:         aws.StringSlice([]string{"intel", "amd"}),
+				BurstablePerformance:     aws.String("excluded"),
+				BareMetal:                aws.String("excluded"),
+				BaselineEbsBandwidthMbps: &autoscaling.BaselineEbsBandwidthMbpsRequest{Min: aws.Int64(10000)},
+				InstanceGenerations:      aws.StringSlice([]string{"current"}),
+				AcceleratorCount:         &autoscaling.AcceleratorCountRequest{Max: aws.Int64(0)},
+			},
+		},
+		{
+			name:         "minimum EBS bandwidth",
+			instanceType: "m5.4xlarge",
+			config:       Config{MultiplyFactorUpper: 1, MultiplyFactorLower: 1, MinEBSBandwidth: 4750},
+			want: &autoscaling.InstanceRequirements{
+				VCpuCount:                &autoscaling.VCpuCountRequest{Min: aws.Int64(16), Max: aws.Int64(16)},
+				MemoryMiB:                &autoscaling.MemoryMiBRequest{Min: aws.Int64(65536), Max: aws.Int64(65536)},
+				MemoryGiBPerVCpu:         &autoscaling.MemoryGiBPerVCpuRequest{Min: aws.Float64(3), Max: aws.Float64(5)},
+				CpuManufacturers:         aws.StringSlice([]string{"intel", "amd"}),
+				BurstablePerformance:     aws.String("excluded"),
+				BareMetal:                aws.String("excluded"),
+				BaselineEbsBandwidthMbps: &autoscaling.BaselineEbsBandwidthMbpsRequest{Min: aws.Int64(4750)},
+				InstanceGenerations:      aws.StringSlice([]string{"current"}),
+				AcceleratorCount:         &autoscaling.AcceleratorCountRequest{Max: aws.Int64(0)},
+			},
+		},
+		{
+			name:         "bare metal with GPU",
+			instanceType: "g4dn.metal",
+			config:       Config{IgnoreFamily: true, MultiplyFactorUpper: 1, MultiplyFactorLower: 1},
+			want: &autoscaling.InstanceRequirements{
+				VCpuCount:                &autoscaling.VCpuCountRequest{Min: aws.Int64(96), Max: aws.Int64(96)},
+				MemoryMiB:                &autoscaling.MemoryMiBRequest{Min: aws.Int64(393216), Max: aws.Int64(393216)},
+				CpuManufacturers:         aws.StringSlice([]string{"intel", "amd"}),
+				BurstablePerformance:     aws.String("excluded"),
+				BaselineEbsBandwidthMbps: &autoscaling.BaselineEbsBandwidthMbpsRequest{Min: aws.Int64(19000)},
+				BareMetal:                aws.String("required"),
+				LocalStorage:             aws.String("required"),
+				InstanceGenerations:      aws.StringSlice([]string{"current"}),
+				AcceleratorCount:         &autoscaling.AcceleratorCountRequest{Min: aws.Int64(8)},
+				AcceleratorTypes:         aws.StringSlice([]string{"gpu"}),
+			},
+		},
+		{
+			name:         "fail: unknown instance type",
+			instanceType: "x0.tiny",
+			config:       config,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetInstanceRequirements(tt.instanceType, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetInstanceRequirements() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetInstanceRequirements() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package ec2
 
 import (
+	"fmt"
 	"log"
 	"sort"
 	"strings"
@@ -22,15 +23,27 @@ func init() {
 	ec2data = data
 }
 
-// InstanceTypeWeight EC2 instance type record that contains type name and weight (equal to the VCPU number)
+// InstanceTypeWeight EC2 instance type record that contains type name, weight (equal to the VCPU number) and memory
 type InstanceTypeWeight struct {
 	// InstanceType instance type name, like `m5.4xlarge`
 	InstanceType string
 	// Weight instance weight for MixedInstancePolicy; equal to the VCPU number
 	Weight int
+	// Memory instance memory in GiB
+	Memory float64
 	// spotPrice    float32 // spot price
 }
 
+// DescribeInstanceType returns the EC2 instance type record
+func DescribeInstanceType(instanceType string) (InstanceTypeWeight, error) {
+	for _, it := range *ec2data {
+		if it.InstanceType == instanceType {
+			return InstanceTypeWeight{it.InstanceType, it.VCPU, float64(it.Memory)}, nil
+		}
+	}
+	return InstanceTypeWeight{}, fmt.Errorf("unknown instance type %v", instanceType)
+}
+
 // A Config is used for tuning the EC2 similarity algorithm
 type Config struct {
 	// IgnoreFamily ignore instance family
@@ -41,6 +54,30 @@ type Config struct {
 	MultiplyFactorUpper int
 	// MultiplyFactorUpper a multiplier for the lower limit of the VCPU size
 	MultiplyFactorLower int
+	// Architecture find similar instance types of another CPU architecture (for example, `arm64` for Graviton);
+	// defaults to the architecture of the original instance type
+	Architecture string
+	// MemoryPerVCPUTolerance the maximum relative difference (0.25 for 25%) between memory per VCPU of similar
+	// and original instance types; 0 does not compare memory per VCPU
+	MemoryPerVCPUTolerance float64
+	// MinMemory the minimum memory (GiB) of similar instance types; 0 for no lower bound
+	MinMemory float64
+	// MaxMemory the maximum memory (GiB) of similar instance types; 0 for no upper bound
+	MaxMemory float64
+	// IgnoreInstanceStore allow similar instance types without instance store volumes, when the original instance type has them
+	IgnoreInstanceStore bool
+	// MinInstanceStore the minimum total instance store size (GB) of similar instance types;
+	// 0 requires instance store volumes only if the original instance type has them
+	MinInstanceStore float64
+	// IgnoreNetworkPerformance allow similar instance types with network performance below the original network performance
+	// (sustained network performance of 25 Gbps and more, peak network performance otherwise)
+	IgnoreNetworkPerformance bool
+	// MinNetworkPerformance the minimum sustained network performance (Gbps) of similar instance types; 0 for no minimum
+	MinNetworkPerformance float64
+	// IgnoreEBSBandwidth allow similar instance types with EBS bandwidth below the original EBS bandwidth of 10000 Mbps and more
+	IgnoreEBSBandwidth bool
+	// MinEBSBandwidth the minimum sustained EBS bandwidth (Mbps) of similar instance types; 0 for no minimum
+	MinEBSBandwidth float64
 }
 
 // GetSimilarTypes find EC2 instances, that are similar to the specified EC2 instance type.
@@ -54,6 +91,12 @@ func GetSimilarTypes(instanceType string, config Config) []InstanceTypeWeight {
 		}
 		// found original instance type
 		original := it
+		arch := original.Arch
+		// cross architecture: the original instance type is not a candidate
+		crossArch := config.Architecture != "" && !contains(original.Arch, config.Architecture)
+		if crossArch {
+			arch = []string{config.Architecture}
+		}
 		// find similar instances
 		for checkedIdx, nt := range *ec2data {
 			// skip original instance type, it will be added later as a 1st element
@@ -61,12 +104,16 @@ func GetSimilarTypes(instanceType string, config Config) []InstanceTypeWeight {
 				continue
 			}
 			if isSimilarGPU(original.GPU, nt.GPU) &&
-				isSimilarCPU(original.VCPU, nt.VCPU, original.Arch, nt.Arch, config.MultiplyFactorUpper, config.MultiplyFactorLower) &&
+				isSimilarCPU(original.VCPU, nt.VCPU, arch, nt.Arch, config.MultiplyFactorUpper, config.MultiplyFactorLower) &&
+				isSimilarMemory(float64(original.Memory), original.VCPU, float64(nt.Memory), nt.VCPU, config) &&
+				isSimilarStorage(instanceStoreSize(original.Storage), instanceStoreSize(nt.Storage), config) &&
+				isSimilarNetwork(original.NetworkPerformance, nt.NetworkPerformance, config) &&
+				isSimilarEBS(original.EBSMaxBandwidth, nt.EBSMaxBandwidth, burstableNetwork(nt.NetworkPerformance), config) &&
 				isSimilarKind(
 					original.Family, original.InstanceType, original.Generation,
 					nt.Family, nt.InstanceType, nt.Generation,
 					config.IgnoreFamily, config.IgnoreGeneration) {
-				candidates = append(candidates, InstanceTypeWeight{nt.InstanceType, nt.VCPU})
+				candidates = append(candidates, InstanceTypeWeight{nt.InstanceType, nt.VCPU, float64(nt.Memory)})
 			}
 		}
 		// sort candidates by Weight, keep original weight first
@@ -79,7 +126,9 @@ func GetSimilarTypes(instanceType string, config Config) []InstanceTypeWeight {
 			return candidates[i].Weight < candidates[j].Weight
 		})
 		// prepend 1st element
-		candidates = append([]InstanceTypeWeight{{original.InstanceType, original.VCPU}}, candidates...)
+		if !crossArch {
+			candidates = append([]InstanceTypeWeight{{original.InstanceType, original.VCPU, float64(original.Memory)}}, candidates...)
+		}
 		// no need to continue
 		break
 	}
@@ -117,6 +166,23 @@ func isSimilarCPU(oCPU, nCPU int, oArch, nArch []string, factorUp, factorLow int
 	return nCPU <= oCPU*factorUp && nCPU >= oCPU/factorLow
 }
 
+// memory within [MinMemory, MaxMemory] bounds
+// and memory per VCPU within the tolerance of the original memory per VCPU
+func isSimilarMemory(oMemory float64, oCPU int, nMemory float64, nCPU int, config Config) bool {
+	if (config.MinMemory > 0 && nMemory < config.MinMemory) || (config.MaxMemory > 0 && nMemory > config.MaxMemory) {
+		return false
+	}
+	if config.MemoryPerVCPUTolerance <= 0 {
+		return true
+	}
+	// unknown memory or VCPU: cannot compare
+	if oCPU <= 0 || nCPU <= 0 || oMemory <= 0 {
+		return false
+	}
+	ratio := nMemory / float64(nCPU) / (oMemory / float64(oCPU))
+	return ratio >= 1-config.MemoryPerVCPUTolerance && ratio <= 1+config.MemoryPerVCPUTolerance
+}
+
 // similar kind
 // 1. the same instance family
 // 2. the same instance type
@@ -147,3 +213,12 @@ func isSimilarKind(oFamily, oType, oGeneration, nFamily, nType, nGeneration stri
 	// OK: similar kind
 	return true
 }
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
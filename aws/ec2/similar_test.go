@@ -4,6 +4,12 @@ import (
 	"testing"
 )
 
+// instance type and weight of the expected similar instance type
+type typeWeight struct {
+	InstanceType string
+	Weight       int
+}
+
 //nolint:funlen
 func Test_GetSimilarTypes(t *testing.T) {
 	type args struct {
@@ -13,7 +19,7 @@ func Test_GetSimilarTypes(t *testing.T) {
 	tests := []struct {
 		name string
 		args args
-		want []InstanceTypeWeight
+		want []typeWeight
 	}{
 		{
 			"get candidates for m5.4xlarge: general purpose 16vCPU",
@@ -26,7 +32,7 @@ func Test_GetSimilarTypes(t *testing.T) {
 					MultiplyFactorLower: 2,
 				},
 			},
-			[]InstanceTypeWeight{
+			[]typeWeight{
 				{"m5.4xlarge", 16},
 				{"m4.4xlarge", 16},
 				{"m5n.4xlarge", 16},
@@ -63,7 +69,7 @@ func Test_GetSimilarTypes(t *testing.T) {
 					MultiplyFactorLower: 2,
 				},
 			},
-			[]InstanceTypeWeight{
+			[]typeWeight{
 				{"m5.4xlarge", 16},
 				{"m4.4xlarge", 16},
 				{"m5n.4xlarge", 16},
@@ -93,7 +99,7 @@ func Test_GetSimilarTypes(t *testing.T) {
 					MultiplyFactorLower: 2,
 				},
 			},
-			[]InstanceTypeWeight{
+			[]typeWeight{
 				{"t3.large", 2},
 				{"t3.medium", 2},
 				{"t2.large", 2},
@@ -114,6 +120,50 @@ func Test_GetSimilarTypes(t *testing.T) {
 				{"t3a.xlarge", 4},
 			},
 		},
+		{
+			"get candidates for m5n.8xlarge: sustained 25 Gigabit network",
+			args{
+				"m5n.8xlarge",
+				Config{
+					IgnoreFamily:        false,
+					IgnoreGeneration:    false,
+					MultiplyFactorUpper: 2,
+					MultiplyFactorLower: 2,
+				},
+			},
+			[]typeWeight{
+				{"m5n.8xlarge", 32},
+				{"m5dn.8xlarge", 32},
+				{"m5zn.6xlarge", 24},
+				{"m5n.12xlarge", 48},
+				{"m5dn.12xlarge", 48},
+				{"m5zn.12xlarge", 48},
+				{"m5n.16xlarge", 64},
+				{"m5dn.16xlarge", 64},
+				{"m4.16xlarge", 64},
+			},
+		},
+		{
+			"get arm64 candidates for m5.4xlarge: cross architecture",
+			args{
+				"m5.4xlarge",
+				Config{
+					IgnoreFamily:        false,
+					IgnoreGeneration:    false,
+					MultiplyFactorUpper: 2,
+					MultiplyFactorLower: 2,
+					Architecture:        "arm64",
+				},
+			},
+			[]typeWeight{
+				{"m6g.4xlarge", 16},
+				{"m6gd.4xlarge", 16},
+				{"m6g.2xlarge", 8},
+				{"m6gd.2xlarge", 8},
+				{"m6g.8xlarge", 32},
+				{"m6gd.8xlarge", 32},
+			},
+		},
 		{
 			"get candidates for c5g.xlarge: graviron2 arm 4 vPCU",
 			args{
@@ -125,7 +175,7 @@ func Test_GetSimilarTypes(t *testing.T) {
 					MultiplyFactorLower: 2,
 				},
 			},
-			[]InstanceTypeWeight{
+			[]typeWeight{
 				{"c6g.xlarge", 4},
 				{"c6gn.xlarge", 4},
 				{"c6gd.xlarge", 4},
@@ -150,6 +200,58 @@ func Test_GetSimilarTypes(t *testing.T) {
 				if got[i].Weight != tt.want[i].Weight {
 					t.Errorf("GetSimilarTypes() sorted weight = %v, want %v", got[i].Weight, tt.want[i].Weight)
 				}
+				if got[i].Memory <= 0 {
+					t.Errorf("GetSimilarTypes() %v memory = %v, want positive memory", got[i].InstanceType, got[i].Memory)
+				}
+			}
+		})
+	}
+}
+
+func Test_GetSimilarTypes_memory(t *testing.T) {
+	// r5.2xlarge: 8 VCPU, 64 GiB (8 GiB per VCPU)
+	config := Config{IgnoreFamily: true, MultiplyFactorUpper: 2, MultiplyFactorLower: 2, MemoryPerVCPUTolerance: 0.25, MaxMemory: 128}
+	got := GetSimilarTypes("r5.2xlarge", config)
+	if len(got) < 2 {
+		t.Fatalf("GetSimilarTypes() result size = %v, want similar instance types", len(got))
+	}
+	for _, it := range got[1:] {
+		if perVCPU := it.Memory / float64(it.Weight); perVCPU < 6 || perVCPU > 10 {
+			t.Errorf("GetSimilarTypes() %v memory per VCPU = %v, want 6-10 GiB", it.InstanceType, perVCPU)
+		}
+		if it.Memory > 128 {
+			t.Errorf("GetSimilarTypes() %v memory = %v, want at most 128 GiB", it.InstanceType, it.Memory)
+		}
+	}
+}
+
+func Test_isSimilarMemory(t *testing.T) {
+	type args struct {
+		oMemory float64
+		oCPU    int
+		nMemory float64
+		nCPU    int
+		config  Config
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{"no memory constraints", args{64, 8, 16, 8, Config{}}, true},
+		{"same memory per VCPU", args{64, 8, 128, 16, Config{MemoryPerVCPUTolerance: 0.1}}, true},
+		{"memory per VCPU within tolerance", args{64, 8, 48, 8, Config{MemoryPerVCPUTolerance: 0.25}}, true},
+		{"fail: memory per VCPU above tolerance", args{64, 8, 16, 8, Config{MemoryPerVCPUTolerance: 0.25}}, false},
+		{"fail: memory per VCPU below tolerance", args{16, 8, 64, 8, Config{MemoryPerVCPUTolerance: 0.25}}, false},
+		{"fail: unknown memory", args{0, 8, 64, 8, Config{MemoryPerVCPUTolerance: 0.25}}, false},
+		{"memory within bounds", args{64, 8, 32, 8, Config{MinMemory: 32, MaxMemory: 64}}, true},
+		{"fail: memory below minimum", args{64, 8, 16, 4, Config{MinMemory: 32}}, false},
+		{"fail: memory above maximum", args{64, 8, 128, 16, Config{MaxMemory: 64}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSimilarMemory(tt.args.oMemory, tt.args.oCPU, tt.args.nMemory, tt.args.nCPU, tt.args.config); got != tt.want {
+				t.Errorf("isSimilarMemory() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -0,0 +1,89 @@
+package ec2
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/doitintl/spotzero/aws/sts"
+)
+
+const (
+	linuxProductDescription    = "Linux/UNIX"
+	maxSpotPricesReturnedByAPI = 1000
+)
+
+// define interface for used methods only (simplify testing)
+type spotPriceHistoryDescriber interface {
+	DescribeSpotPriceHistoryPagesWithContext(aws.Context, *ec2.DescribeSpotPriceHistoryInput, func(*ec2.DescribeSpotPriceHistoryOutput, bool) bool, ...request.Option) error
+}
+
+type spotPriceService struct {
+	svc spotPriceHistoryDescriber
+}
+
+// SpotPriceDescriber contains methods for getting current EC2 Spot prices
+type SpotPriceDescriber interface {
+	GetSpotPrices(ctx context.Context, zones []string) (map[string]float64, error)
+}
+
+// NewSpotPriceDescriber create new SpotPriceDescriber
+func NewSpotPriceDescriber(role sts.AssumeRoleInRegion) SpotPriceDescriber {
+	return &spotPriceService{
+		svc: ec2.New(sts.MustAwsSession(role.Arn, role.ExternalID, role.Region)),
+	}
+}
+
+// GetSpotPrices returns current hourly Linux Spot prices of instance types, averaged over the Availability Zones;
+// all Availability Zones of the region are used, if zones are empty
+func (s *spotPriceService) GetSpotPrices(ctx context.Context, zones []string) (map[string]float64, error) {
+	// the price in effect at the start time is returned for every instance type and zone
+	now := time.Now()
+	input := &ec2.DescribeSpotPriceHistoryInput{
+		StartTime:           aws.Time(now),
+		EndTime:             aws.Time(now),
+		ProductDescriptions: aws.StringSlice([]string{linuxProductDescription}),
+		MaxResults:          aws.Int64(maxSpotPricesReturnedByAPI),
+	}
+	if len(zones) > 0 {
+		input.Filters = []*ec2.Filter{{Name: aws.String("availability-zone"), Values: aws.StringSlice(zones)}}
+	}
+	// latest price for every instance type and zone
+	latest := make(map[string]map[string]*ec2.SpotPrice)
+	err := s.svc.DescribeSpotPriceHistoryPagesWithContext(ctx, input, func(page *ec2.DescribeSpotPriceHistoryOutput, lastPage bool) bool {
+		for _, p := range page.SpotPriceHistory {
+			instanceType, zone := aws.StringValue(p.InstanceType), aws.StringValue(p.AvailabilityZone)
+			if latest[instanceType] == nil {
+				latest[instanceType] = make(map[string]*ec2.SpotPrice)
+			}
+			if l, ok := latest[instanceType][zone]; !ok || aws.TimeValue(p.Timestamp).After(aws.TimeValue(l.Timestamp)) {
+				latest[instanceType][zone] = p
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error describing spot price history: %v", err)
+	}
+	prices := make(map[string]float64, len(latest))
+	for instanceType, zonePrices := range latest {
+		var sum float64
+		var count int
+		for _, p := range zonePrices {
+			price, err := strconv.ParseFloat(aws.StringValue(p.SpotPrice), 64)
+			if err != nil || price <= 0 {
+				continue
+			}
+			sum += price
+			count++
+		}
+		if count > 0 {
+			prices[instanceType] = sum / float64(count)
+		}
+	}
+	return prices, nil
+}
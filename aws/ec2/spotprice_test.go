@@ -0,0 +1,99 @@
+package ec2
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/doitintl/spotzero/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func testSpotPrice(instanceType, zone, price string, age time.Duration) *ec2.SpotPrice {
+	return &ec2.SpotPrice{
+		InstanceType:       aws.String(instanceType),
+		AvailabilityZone:   aws.String(zone),
+		ProductDescription: aws.String(linuxProductDescription),
+		SpotPrice:          aws.String(price),
+		Timestamp:          aws.Time(time.Now().Add(-age)),
+	}
+}
+
+func Test_spotPriceService_GetSpotPrices(t *testing.T) {
+	tests := []struct {
+		name    string
+		zones   []string
+		pages   []*ec2.DescribeSpotPriceHistoryOutput
+		err     error
+		want    map[string]float64
+		wantErr bool
+	}{
+		{
+			name:  "average of latest zone prices",
+			zones: []string{"us-east-1a", "us-east-1b"},
+			pages: []*ec2.DescribeSpotPriceHistoryOutput{
+				{SpotPriceHistory: []*ec2.SpotPrice{
+					testSpotPrice("m5.large", "us-east-1a", "0.040000", time.Hour),
+					testSpotPrice("m5.large", "us-east-1a", "0.030000", 2*time.Hour),
+					testSpotPrice("m5a.large", "us-east-1b", "0.035000", time.Hour),
+				}},
+				{SpotPriceHistory: []*ec2.SpotPrice{
+					testSpotPrice("m5.large", "us-east-1b", "0.020000", time.Hour),
+					testSpotPrice("m5n.large", "us-east-1b", "invalid", time.Hour),
+				}},
+			},
+			want: map[string]float64{"m5.large": 0.03, "m5a.large": 0.035},
+		},
+		{
+			name: "all region zones",
+			pages: []*ec2.DescribeSpotPriceHistoryOutput{
+				{SpotPriceHistory: []*ec2.SpotPrice{testSpotPrice("m5.large", "us-east-1c", "0.040000", time.Hour)}},
+			},
+			want: map[string]float64{"m5.large": 0.04},
+		},
+		{
+			name:    "fail to describe spot price history",
+			err:     errors.New("access denied"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mockSvc := new(mocks.AwsSpotPriceHistoryDescriber)
+			mockSvc.On("DescribeSpotPriceHistoryPagesWithContext", ctx, mock.MatchedBy(func(input *ec2.DescribeSpotPriceHistoryInput) bool {
+				var filters []*ec2.Filter
+				if len(tt.zones) > 0 {
+					filters = []*ec2.Filter{{Name: aws.String("availability-zone"), Values: aws.StringSlice(tt.zones)}}
+				}
+				return reflect.DeepEqual(input.Filters, filters) &&
+					reflect.DeepEqual(aws.StringValueSlice(input.ProductDescriptions), []string{linuxProductDescription})
+			}), mock.Anything).Run(func(args mock.Arguments) {
+				fn := args.Get(2).(func(*ec2.DescribeSpotPriceHistoryOutput, bool) bool)
+				for i, page := range tt.pages {
+					fn(page, i == len(tt.pages)-1)
+				}
+			}).Return(tt.err).Once()
+			s := &spotPriceService{svc: mockSvc}
+			got, err := s.GetSpotPrices(ctx, tt.zones)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetSpotPrices() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("GetSpotPrices() = %v, want %v", got, tt.want)
+			}
+			for instanceType, price := range tt.want {
+				if math.Abs(got[instanceType]-price) > 1e-9 {
+					t.Errorf("GetSpotPrices() %v = %v, want %v", instanceType, got[instanceType], price)
+				}
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
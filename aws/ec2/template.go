@@ -0,0 +1,315 @@
+package ec2
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/doitintl/spotzero/aws/sts"
+)
+
+const (
+	// prefix of launch templates created by spotzero
+	launchTemplateNamePrefix = "spotzero-"
+	maxLaunchTemplateNameLen = 128
+	// length of the source name hash appended to sanitized or truncated launch template names
+	nameHashLen = 8
+	// error code returned by CreateLaunchTemplate for existing launch template name
+	errCodeLaunchTemplateExists = "InvalidLaunchTemplateName.AlreadyExistsException"
+	// error code returned by DescribeLaunchTemplates for missing launch template name
+	errCodeLaunchTemplateNotFound = "InvalidLaunchTemplateName.NotFoundException"
+	// version of a new launch template
+	firstVersion = "1"
+)
+
+// characters not allowed in launch template name
+var invalidLaunchTemplateNameChars = regexp.MustCompile(`[^a-zA-Z0-9().\-/_]`)
+
+// define interface for used methods only (simplify testing)
+type awsLaunchTemplateCreator interface {
+	CreateLaunchTemplateWithContext(aws.Context, *ec2.CreateLaunchTemplateInput, ...request.Option) (*ec2.CreateLaunchTemplateOutput, error)
+	DescribeLaunchTemplatesWithContext(aws.Context, *ec2.DescribeLaunchTemplatesInput, ...request.Option) (*ec2.DescribeLaunchTemplatesOutput, error)
+	DescribeLaunchTemplateVersionsWithContext(aws.Context, *ec2.DescribeLaunchTemplateVersionsInput, ...request.Option) (*ec2.DescribeLaunchTemplateVersionsOutput, error)
+}
+
+type ltCreatorService struct {
+	svc awsLaunchTemplateCreator
+}
+
+// LaunchTemplateCreator contains methods for creating EC2 launch templates
+type LaunchTemplateCreator interface {
+	CreateFromLaunchConfiguration(ctx context.Context, lc *autoscaling.LaunchConfiguration) (*autoscaling.LaunchTemplateSpecification, error)
+	PreviewFromLaunchConfiguration(ctx context.Context, lc *autoscaling.LaunchConfiguration) (*autoscaling.LaunchTemplateSpecification, error)
+	CreateWithImage(ctx context.Context, source *autoscaling.LaunchTemplateSpecification, imageID string) (*autoscaling.LaunchTemplateSpecification, error)
+	ImageLaunchTemplateName(ctx context.Context, source *autoscaling.LaunchTemplateSpecification, imageID string) (string, error)
+}
+
+// NewLaunchTemplateCreator create new LaunchTemplateCreator
+func NewLaunchTemplateCreator(role sts.AssumeRoleInRegion) LaunchTemplateCreator {
+	return &ltCreatorService{
+		svc: ec2.New(sts.MustAwsSession(role.Arn, role.ExternalID, role.Region)),
+	}
+}
+
+// CreateFromLaunchConfiguration creates an EC2 launch template equivalent to the provided launch configuration.
+// Launch configurations are immutable, so the launch template, created earlier for the same launch configuration, is reused.
+// It returns the launch template specification (ID and version).
+func (s *ltCreatorService) CreateFromLaunchConfiguration(ctx context.Context, lc *autoscaling.LaunchConfiguration) (*autoscaling.LaunchTemplateSpecification, error) {
+	name := launchTemplateName(aws.StringValue(lc.LaunchConfigurationName))
+	input := &ec2.CreateLaunchTemplateInput{
+		LaunchTemplateName: aws.String(name),
+		LaunchTemplateData: launchTemplateData(lc),
+		VersionDescription: aws.String(fmt.Sprintf("created by spotzero from launch configuration %v", aws.StringValue(lc.LaunchConfigurationName))),
+	}
+	output, err := s.svc.CreateLaunchTemplateWithContext(ctx, input)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == errCodeLaunchTemplateExists {
+			return s.describeLaunchTemplate(ctx, name)
+		}
+		return nil, fmt.Errorf("error creating launch template: %v", err)
+	}
+	return templateSpec(output.LaunchTemplate), nil
+}
+
+// PreviewFromLaunchConfiguration returns the launch template specification, that CreateFromLaunchConfiguration returns, without creating it:
+// the launch template, created earlier for the same launch configuration, or the first version of the new launch template (by name).
+func (s *ltCreatorService) PreviewFromLaunchConfiguration(ctx context.Context, lc *autoscaling.LaunchConfiguration) (*autoscaling.LaunchTemplateSpecification, error) {
+	name := launchTemplateName(aws.StringValue(lc.LaunchConfigurationName))
+	output, err := s.svc.DescribeLaunchTemplatesWithContext(ctx, &ec2.DescribeLaunchTemplatesInput{
+		LaunchTemplateNames: []*string{aws.String(name)},
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == errCodeLaunchTemplateNotFound {
+			return &autoscaling.LaunchTemplateSpecification{LaunchTemplateName: aws.String(name), Version: aws.String(firstVersion)}, nil
+		}
+		return nil, fmt.Errorf("error describing launch template: %v", err)
+	}
+	if len(output.LaunchTemplates) != 1 {
+		return nil, fmt.Errorf("expected to get a single launch template %v", name)
+	}
+	return templateSpec(output.LaunchTemplates[0]), nil
+}
+
+// LaunchConfigurationInstance returns instance details of the launch template data, converted from the launch configuration
+func LaunchConfigurationInstance(lc *autoscaling.LaunchConfiguration) *InstanceDetails {
+	data := launchTemplateData(lc)
+	marketType := OnDemandMarketType
+	if data.InstanceMarketOptions != nil {
+		marketType = aws.StringValue(data.InstanceMarketOptions.MarketType)
+	}
+	return &InstanceDetails{TypeName: aws.StringValue(data.InstanceType), MarketType: marketType}
+}
+
+// CreateWithImage creates a copy of the source launch template version with another AMI (for example, arm64 AMI for Graviton instance types).
+// The launch template, created earlier for the same source launch template version and AMI, is reused.
+// It returns the launch template specification (ID and version).
+func (s *ltCreatorService) CreateWithImage(ctx context.Context, source *autoscaling.LaunchTemplateSpecification, imageID string) (*autoscaling.LaunchTemplateSpecification, error) {
+	version, err := s.describeLaunchTemplateVersion(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	data, err := requestLaunchTemplateData(version.LaunchTemplateData)
+	if err != nil {
+		return nil, err
+	}
+	data.ImageId = aws.String(imageID)
+	name := imageLaunchTemplateName(version, imageID)
+	output, err := s.svc.CreateLaunchTemplateWithContext(ctx, &ec2.CreateLaunchTemplateInput{
+		LaunchTemplateName: aws.String(name),
+		LaunchTemplateData: data,
+		VersionDescription: aws.String(fmt.Sprintf("created by spotzero from launch template %v version %d with image %v",
+			aws.StringValue(version.LaunchTemplateName), aws.Int64Value(version.VersionNumber), imageID)),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == errCodeLaunchTemplateExists {
+			return s.describeLaunchTemplate(ctx, name)
+		}
+		return nil, fmt.Errorf("error creating launch template: %v", err)
+	}
+	return templateSpec(output.LaunchTemplate), nil
+}
+
+// ImageLaunchTemplateName returns the name of the launch template, that CreateWithImage creates, without creating it
+func (s *ltCreatorService) ImageLaunchTemplateName(ctx context.Context, source *autoscaling.LaunchTemplateSpecification, imageID string) (string, error) {
+	version, err := s.describeLaunchTemplateVersion(ctx, source)
+	if err != nil {
+		return "", err
+	}
+	return imageLaunchTemplateName(version, imageID), nil
+}
+
+func (s *ltCreatorService) describeLaunchTemplateVersion(ctx context.Context, spec *autoscaling.LaunchTemplateSpecification) (*ec2.LaunchTemplateVersion, error) {
+	output, err := s.svc.DescribeLaunchTemplateVersionsWithContext(ctx, &ec2.DescribeLaunchTemplateVersionsInput{
+		LaunchTemplateId:   spec.LaunchTemplateId,
+		LaunchTemplateName: spec.LaunchTemplateName,
+		Versions:           []*string{spec.Version},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error describing launch template version: %v", err)
+	}
+	if len(output.LaunchTemplateVersions) != 1 || output.LaunchTemplateVersions[0].LaunchTemplateData == nil {
+		return nil, errors.New("expected to get a single launch template version")
+	}
+	return output.LaunchTemplateVersions[0], nil
+}
+
+// imageLaunchTemplateName returns a valid launch template name for the launch template version and AMI;
+// the version number is resolved, so a new source version gets a new launch template
+func imageLaunchTemplateName(version *ec2.LaunchTemplateVersion, imageID string) string {
+	return ImageTemplateName(aws.StringValue(version.LaunchTemplateName), aws.Int64Value(version.VersionNumber), imageID)
+}
+
+// ImageTemplateName returns the name of the launch template, that CreateWithImage creates for the source launch template name and version number
+func ImageTemplateName(name string, version int64, imageID string) string {
+	return launchTemplateName(fmt.Sprintf("%s-v%d-%s", name, version, imageID))
+}
+
+// requestLaunchTemplateData converts the described launch template data into request data;
+// response and request structures share field names
+func requestLaunchTemplateData(response *ec2.ResponseLaunchTemplateData) (*ec2.RequestLaunchTemplateData, error) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("error converting launch template data: %v", err)
+	}
+	var request ec2.RequestLaunchTemplateData
+	if err = json.Unmarshal(data, &request); err != nil {
+		return nil, fmt.Errorf("error converting launch template data: %v", err)
+	}
+	return &request, nil
+}
+
+func (s *ltCreatorService) describeLaunchTemplate(ctx context.Context, name string) (*autoscaling.LaunchTemplateSpecification, error) {
+	output, err := s.svc.DescribeLaunchTemplatesWithContext(ctx, &ec2.DescribeLaunchTemplatesInput{
+		LaunchTemplateNames: []*string{aws.String(name)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error describing launch template: %v", err)
+	}
+	if len(output.LaunchTemplates) != 1 {
+		return nil, fmt.Errorf("expected to get a single launch template %v", name)
+	}
+	return templateSpec(output.LaunchTemplates[0]), nil
+}
+
+func templateSpec(lt *ec2.LaunchTemplate) *autoscaling.LaunchTemplateSpecification {
+	return &autoscaling.LaunchTemplateSpecification{
+		LaunchTemplateId: lt.LaunchTemplateId,
+		Version:          aws.String(strconv.FormatInt(aws.Int64Value(lt.DefaultVersionNumber), 10)),
+	}
+}
+
+// launchTemplateName returns a valid launch template name for the launch configuration (or other source name);
+// if invalid characters are replaced or the name is truncated, a hash of the source name keeps different sources apart
+func launchTemplateName(sourceName string) string {
+	sanitized := invalidLaunchTemplateNameChars.ReplaceAllString(sourceName, "-")
+	name := launchTemplateNamePrefix + sanitized
+	if sanitized == sourceName && len(name) <= maxLaunchTemplateNameLen {
+		return name
+	}
+	sum := sha256.Sum256([]byte(sourceName))
+	suffix := "-" + hex.EncodeToString(sum[:])[:nameHashLen]
+	if len(name)+len(suffix) > maxLaunchTemplateNameLen {
+		name = name[:maxLaunchTemplateNameLen-len(suffix)]
+	}
+	return name + suffix
+}
+
+// launchTemplateData converts the launch configuration into the launch template data:
+// AMI, instance type, user data, security groups, block devices, IAM instance profile, key pair,
+// monitoring, tenancy, metadata options and public IP address association.
+func launchTemplateData(lc *autoscaling.LaunchConfiguration) *ec2.RequestLaunchTemplateData {
+	data := &ec2.RequestLaunchTemplateData{
+		ImageId:      lc.ImageId,
+		InstanceType: lc.InstanceType,
+		KeyName:      lc.KeyName,
+		UserData:     lc.UserData,
+		KernelId:     lc.KernelId,
+		RamDiskId:    lc.RamdiskId,
+		EbsOptimized: lc.EbsOptimized,
+	}
+	// security groups: IDs for VPC, names for EC2-Classic
+	var groupIds, groupNames []*string
+	for _, sg := range lc.SecurityGroups {
+		if strings.HasPrefix(aws.StringValue(sg), "sg-") {
+			groupIds = append(groupIds, sg)
+		} else {
+			groupNames = append(groupNames, sg)
+		}
+	}
+	data.SecurityGroups = groupNames
+	if lc.AssociatePublicIpAddress != nil {
+		// public IP address is configured on the primary network interface together with security groups
+		data.NetworkInterfaces = []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
+			{
+				AssociatePublicIpAddress: lc.AssociatePublicIpAddress,
+				DeleteOnTermination:      aws.Bool(true),
+				DeviceIndex:              aws.Int64(0),
+				Groups:                   groupIds,
+			},
+		}
+	} else {
+		data.SecurityGroupIds = groupIds
+	}
+	// IAM instance profile: name or ARN
+	if profile := aws.StringValue(lc.IamInstanceProfile); profile != "" {
+		data.IamInstanceProfile = &ec2.LaunchTemplateIamInstanceProfileSpecificationRequest{}
+		if strings.HasPrefix(profile, "arn:") {
+			data.IamInstanceProfile.Arn = lc.IamInstanceProfile
+		} else {
+			data.IamInstanceProfile.Name = lc.IamInstanceProfile
+		}
+	}
+	for _, bd := range lc.BlockDeviceMappings {
+		mapping := &ec2.LaunchTemplateBlockDeviceMappingRequest{
+			DeviceName:  bd.DeviceName,
+			VirtualName: bd.VirtualName,
+		}
+		if aws.BoolValue(bd.NoDevice) {
+			mapping.NoDevice = aws.String("")
+		}
+		if bd.Ebs != nil {
+			mapping.Ebs = &ec2.LaunchTemplateEbsBlockDeviceRequest{
+				DeleteOnTermination: bd.Ebs.DeleteOnTermination,
+				Encrypted:           bd.Ebs.Encrypted,
+				Iops:                bd.Ebs.Iops,
+				SnapshotId:          bd.Ebs.SnapshotId,
+				Throughput:          bd.Ebs.Throughput,
+				VolumeSize:          bd.Ebs.VolumeSize,
+				VolumeType:          bd.Ebs.VolumeType,
+			}
+		}
+		data.BlockDeviceMappings = append(data.BlockDeviceMappings, mapping)
+	}
+	if lc.InstanceMonitoring != nil {
+		data.Monitoring = &ec2.LaunchTemplatesMonitoringRequest{Enabled: lc.InstanceMonitoring.Enabled}
+	}
+	if lc.PlacementTenancy != nil {
+		data.Placement = &ec2.LaunchTemplatePlacementRequest{Tenancy: lc.PlacementTenancy}
+	}
+	if m := lc.MetadataOptions; m != nil {
+		data.MetadataOptions = &ec2.LaunchTemplateInstanceMetadataOptionsRequest{
+			HttpEndpoint:            m.HttpEndpoint,
+			HttpPutResponseHopLimit: m.HttpPutResponseHopLimit,
+			HttpTokens:              m.HttpTokens,
+		}
+	}
+	// launch configuration with Spot price requests Spot instances
+	if lc.SpotPrice != nil {
+		data.InstanceMarketOptions = &ec2.LaunchTemplateInstanceMarketOptionsRequest{
+			MarketType:  aws.String(SpotMarketType),
+			SpotOptions: &ec2.LaunchTemplateSpotMarketOptionsRequest{MaxPrice: lc.SpotPrice},
+		}
+	}
+	return data
+}
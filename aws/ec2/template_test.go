@@ -0,0 +1,256 @@
+package ec2
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/doitintl/spotzero/mocks"
+)
+
+func Test_launchTemplateName(t *testing.T) {
+	tests := []struct {
+		name   string
+		lcName string
+		want   string
+	}{
+		{name: "valid name", lcName: "web-lc_v1.2", want: "spotzero-web-lc_v1.2"},
+		{name: "invalid characters", lcName: "web lc:v1", want: "spotzero-web-lc-v1-81469c30"},
+		{name: "long name", lcName: strings.Repeat("a", 200), want: "spotzero-" + strings.Repeat("a", 110) + "-c2a908d9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := launchTemplateName(tt.lcName)
+			if got != tt.want {
+				t.Errorf("launchTemplateName() = %v, want %v", got, tt.want)
+			}
+			if len(got) > maxLaunchTemplateNameLen {
+				t.Errorf("launchTemplateName() length = %v, want <= %v", len(got), maxLaunchTemplateNameLen)
+			}
+		})
+	}
+}
+
+func Test_launchTemplateName_collision(t *testing.T) {
+	long := strings.Repeat("a", 200)
+	names := [][2]string{
+		{long + "-web", long + "-api"},
+		{"web lc", "web:lc"},
+	}
+	for _, n := range names {
+		if a, b := launchTemplateName(n[0]), launchTemplateName(n[1]); a == b {
+			t.Errorf("launchTemplateName(%q) = launchTemplateName(%q) = %v", n[0], n[1], a)
+		}
+	}
+}
+
+//nolint:funlen
+func Test_launchTemplateData(t *testing.T) {
+	tests := []struct {
+		name string
+		lc   *autoscaling.LaunchConfiguration
+		want *ec2.RequestLaunchTemplateData
+	}{
+		{
+			name: "convert launch configuration",
+			lc: &autoscaling.LaunchConfiguration{
+				ImageId:            aws.String("ami-1"),
+				InstanceType:       aws.String("m5.large"),
+				KeyName:            aws.String("key"),
+				UserData:           aws.String("ZWNobyBoZWxsbw=="),
+				SecurityGroups:     aws.StringSlice([]string{"sg-1", "sg-2"}),
+				IamInstanceProfile: aws.String("web-profile"),
+				BlockDeviceMappings: []*autoscaling.BlockDeviceMapping{
+					{
+						DeviceName: aws.String("/dev/xvda"),
+						Ebs:        &autoscaling.Ebs{VolumeSize: aws.Int64(20), VolumeType: aws.String("gp3"), Encrypted: aws.Bool(true)},
+					},
+					{DeviceName: aws.String("/dev/xvdb"), NoDevice: aws.Bool(true)},
+				},
+				InstanceMonitoring: &autoscaling.InstanceMonitoring{Enabled: aws.Bool(false)},
+			},
+			want: &ec2.RequestLaunchTemplateData{
+				ImageId:            aws.String("ami-1"),
+				InstanceType:       aws.String("m5.large"),
+				KeyName:            aws.String("key"),
+				UserData:           aws.String("ZWNobyBoZWxsbw=="),
+				SecurityGroupIds:   aws.StringSlice([]string{"sg-1", "sg-2"}),
+				IamInstanceProfile: &ec2.LaunchTemplateIamInstanceProfileSpecificationRequest{Name: aws.String("web-profile")},
+				BlockDeviceMappings: []*ec2.LaunchTemplateBlockDeviceMappingRequest{
+					{
+						DeviceName: aws.String("/dev/xvda"),
+						Ebs:        &ec2.LaunchTemplateEbsBlockDeviceRequest{VolumeSize: aws.Int64(20), VolumeType: aws.String("gp3"), Encrypted: aws.Bool(true)},
+					},
+					{DeviceName: aws.String("/dev/xvdb"), NoDevice: aws.String("")},
+				},
+				Monitoring: &ec2.LaunchTemplatesMonitoringRequest{Enabled: aws.Bool(false)},
+			},
+		},
+		{
+			name: "convert launch configuration with public IP address",
+			lc: &autoscaling.LaunchConfiguration{
+				ImageId:                  aws.String("ami-1"),
+				InstanceType:             aws.String("m5.large"),
+				SecurityGroups:           aws.StringSlice([]string{"sg-1"}),
+				IamInstanceProfile:       aws.String("arn:aws:iam::123456789012:instance-profile/web"),
+				AssociatePublicIpAddress: aws.Bool(true),
+				PlacementTenancy:         aws.String("dedicated"),
+			},
+			want: &ec2.RequestLaunchTemplateData{
+				ImageId:      aws.String("ami-1"),
+				InstanceType: aws.String("m5.large"),
+				NetworkInterfaces: []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
+					{
+						AssociatePublicIpAddress: aws.Bool(true),
+						DeleteOnTermination:      aws.Bool(true),
+						DeviceIndex:              aws.Int64(0),
+						Groups:                   aws.StringSlice([]string{"sg-1"}),
+					},
+				},
+				IamInstanceProfile: &ec2.LaunchTemplateIamInstanceProfileSpecificationRequest{Arn: aws.String("arn:aws:iam::123456789012:instance-profile/web")},
+				Placement:          &ec2.LaunchTemplatePlacementRequest{Tenancy: aws.String("dedicated")},
+			},
+		},
+		{
+			name: "convert spot launch configuration with EC2-Classic security groups",
+			lc: &autoscaling.LaunchConfiguration{
+				ImageId:        aws.String("ami-1"),
+				InstanceType:   aws.String("m5.large"),
+				SecurityGroups: aws.StringSlice([]string{"default"}),
+				SpotPrice:      aws.String("0.05"),
+			},
+			want: &ec2.RequestLaunchTemplateData{
+				ImageId:        aws.String("ami-1"),
+				InstanceType:   aws.String("m5.large"),
+				SecurityGroups: aws.StringSlice([]string{"default"}),
+				InstanceMarketOptions: &ec2.LaunchTemplateInstanceMarketOptionsRequest{
+					MarketType:  aws.String(SpotMarketType),
+					SpotOptions: &ec2.LaunchTemplateSpotMarketOptionsRequest{MaxPrice: aws.String("0.05")},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := launchTemplateData(tt.lc); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("launchTemplateData() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_imageLaunchTemplateName(t *testing.T) {
+	version := &ec2.LaunchTemplateVersion{LaunchTemplateName: aws.String("web"), VersionNumber: aws.Int64(3)}
+	if got, want := imageLaunchTemplateName(version, "ami-0123"), "spotzero-web-v3-ami-0123"; got != want {
+		t.Errorf("imageLaunchTemplateName() = %v, want %v", got, want)
+	}
+}
+
+func Test_ltCreatorService_PreviewFromLaunchConfiguration(t *testing.T) {
+	lc := &autoscaling.LaunchConfiguration{LaunchConfigurationName: aws.String("web-lc"), InstanceType: aws.String("m5.large")}
+	tests := []struct {
+		name      string
+		templates []*ec2.LaunchTemplate
+		err       error
+		want      *autoscaling.LaunchTemplateSpecification
+		wantErr   bool
+	}{
+		{
+			name:      "existing launch template",
+			templates: []*ec2.LaunchTemplate{{LaunchTemplateId: aws.String("lt-1"), DefaultVersionNumber: aws.Int64(2)}},
+			want:      &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-1"), Version: aws.String("2")},
+		},
+		{
+			name: "new launch template",
+			err:  awserr.New(errCodeLaunchTemplateNotFound, "not found", nil),
+			want: &autoscaling.LaunchTemplateSpecification{LaunchTemplateName: aws.String("spotzero-web-lc"), Version: aws.String("1")},
+		},
+		{
+			name:    "fail to describe launch template",
+			err:     errors.New("access denied"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mockSvc := new(mocks.AwsLaunchTemplateCreator)
+			mockSvc.On("DescribeLaunchTemplatesWithContext", ctx, &ec2.DescribeLaunchTemplatesInput{LaunchTemplateNames: aws.StringSlice([]string{"spotzero-web-lc"})}).
+				Return(&ec2.DescribeLaunchTemplatesOutput{LaunchTemplates: tt.templates}, tt.err).Once()
+			s := &ltCreatorService{svc: mockSvc}
+			got, err := s.PreviewFromLaunchConfiguration(ctx, lc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PreviewFromLaunchConfiguration() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PreviewFromLaunchConfiguration() = %v, want %v", got, tt.want)
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLaunchConfigurationInstance(t *testing.T) {
+	tests := []struct {
+		name string
+		lc   *autoscaling.LaunchConfiguration
+		want *InstanceDetails
+	}{
+		{
+			name: "on-demand",
+			lc:   &autoscaling.LaunchConfiguration{InstanceType: aws.String("m5.large")},
+			want: &InstanceDetails{TypeName: "m5.large", MarketType: OnDemandMarketType},
+		},
+		{
+			name: "spot price",
+			lc:   &autoscaling.LaunchConfiguration{InstanceType: aws.String("m5.large"), SpotPrice: aws.String("0.05")},
+			want: &InstanceDetails{TypeName: "m5.large", MarketType: SpotMarketType},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LaunchConfigurationInstance(tt.lc); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LaunchConfigurationInstance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_requestLaunchTemplateData(t *testing.T) {
+	response := &ec2.ResponseLaunchTemplateData{
+		ImageId:            aws.String("ami-x86"),
+		UserData:           aws.String("ZWNobyBoZWxsbw=="),
+		IamInstanceProfile: &ec2.LaunchTemplateIamInstanceProfileSpecification{Name: aws.String("web-profile")},
+		BlockDeviceMappings: []*ec2.LaunchTemplateBlockDeviceMapping{
+			{DeviceName: aws.String("/dev/xvda"), Ebs: &ec2.LaunchTemplateEbsBlockDevice{VolumeSize: aws.Int64(20), Encrypted: aws.Bool(true)}},
+		},
+		NetworkInterfaces: []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecification{
+			{DeviceIndex: aws.Int64(0), Groups: aws.StringSlice([]string{"sg-1"}), AssociatePublicIpAddress: aws.Bool(true)},
+		},
+	}
+	want := &ec2.RequestLaunchTemplateData{
+		ImageId:            aws.String("ami-x86"),
+		UserData:           aws.String("ZWNobyBoZWxsbw=="),
+		IamInstanceProfile: &ec2.LaunchTemplateIamInstanceProfileSpecificationRequest{Name: aws.String("web-profile")},
+		BlockDeviceMappings: []*ec2.LaunchTemplateBlockDeviceMappingRequest{
+			{DeviceName: aws.String("/dev/xvda"), Ebs: &ec2.LaunchTemplateEbsBlockDeviceRequest{VolumeSize: aws.Int64(20), Encrypted: aws.Bool(true)}},
+		},
+		NetworkInterfaces: []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
+			{DeviceIndex: aws.Int64(0), Groups: aws.StringSlice([]string{"sg-1"}), AssociatePublicIpAddress: aws.Bool(true)},
+		},
+	}
+	got, err := requestLaunchTemplateData(response)
+	if err != nil {
+		t.Fatalf("requestLaunchTemplateData() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("requestLaunchTemplateData() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,115 @@
+// Package organizations simplifies discovery of AWS accounts for multi-account scanning
+package organizations
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/doitintl/spotzero/aws/sts"
+)
+
+const (
+	// AccountPlaceholder is replaced with AWS account ID in the role ARN template
+	AccountPlaceholder = "{account}"
+)
+
+var accountIDRegexp = regexp.MustCompile(`^\d{12}$`)
+
+// define interface for used methods only (simplify testing)
+type awsOrganizations interface {
+	ListAccountsPagesWithContext(aws.Context, *organizations.ListAccountsInput, func(*organizations.ListAccountsOutput, bool) bool, ...request.Option) error
+}
+
+type orgService struct {
+	svc awsOrganizations
+}
+
+type fileService struct {
+	path string
+}
+
+// AccountLister interface contains methods to discover AWS accounts
+type AccountLister interface {
+	ListAccounts(ctx context.Context) ([]string, error)
+}
+
+// NewAccountLister creates a new AccountLister for AWS Organizations member accounts
+func NewAccountLister(role sts.AssumeRoleInRegion) AccountLister {
+	return &orgService{svc: organizations.New(sts.MustAwsSession(role.Arn, role.ExternalID, role.Region))}
+}
+
+// NewFileAccountLister creates a new AccountLister reading AWS account IDs from the local file
+func NewFileAccountLister(path string) AccountLister {
+	return &fileService{path: path}
+}
+
+// ListAccounts list active member accounts of the AWS Organization.
+// It returns a list of AWS account IDs.
+func (s *orgService) ListAccounts(ctx context.Context) ([]string, error) {
+	var accounts []string
+	err := s.svc.ListAccountsPagesWithContext(ctx, &organizations.ListAccountsInput{}, func(p *organizations.ListAccountsOutput, lastPage bool) bool {
+		for _, a := range p.Accounts {
+			if aws.StringValue(a.Status) != organizations.AccountStatusActive {
+				log.Printf("skipping account %v: %v", aws.StringValue(a.Id), aws.StringValue(a.Status))
+				continue
+			}
+			accounts = append(accounts, aws.StringValue(a.Id))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing organization accounts: %v", err)
+	}
+	return accounts, nil
+}
+
+// ListAccounts read AWS account IDs from the file: one account per line, `#` starts a comment.
+// It returns a list of AWS account IDs.
+func (s *fileService) ListAccounts(ctx context.Context) ([]string, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening accounts file: %v", err)
+	}
+	defer f.Close()
+	return parseAccounts(f)
+}
+
+func parseAccounts(r io.Reader) ([]string, error) {
+	var accounts []string
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		account := scanner.Text()
+		if i := strings.Index(account, "#"); i >= 0 {
+			account = account[:i]
+		}
+		account = strings.TrimSpace(account)
+		if account == "" {
+			continue
+		}
+		if !accountIDRegexp.MatchString(account) {
+			return nil, fmt.Errorf("invalid AWS account ID %q at line %d", account, line)
+		}
+		accounts = append(accounts, account)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading accounts: %v", err)
+	}
+	return accounts, nil
+}
+
+// RoleArn creates IAM role ARN for the AWS account from the template
+func RoleArn(template, account string) (string, error) {
+	if !strings.Contains(template, AccountPlaceholder) {
+		return "", fmt.Errorf("role ARN template %q does not contain %s placeholder", template, AccountPlaceholder)
+	}
+	return strings.ReplaceAll(template, AccountPlaceholder, account), nil
+}
@@ -0,0 +1,131 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/doitintl/spotzero/mocks"
+
+	"github.com/aws/aws-sdk-go/aws"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+func Test_orgService_ListAccounts(t *testing.T) {
+	tests := []struct {
+		name     string
+		accounts []*organizations.Account
+		err      error
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name: "active accounts only",
+			accounts: []*organizations.Account{
+				{Id: aws.String("111111111111"), Status: aws.String(organizations.AccountStatusActive)},
+				{Id: aws.String("222222222222"), Status: aws.String(organizations.AccountStatusSuspended)},
+				{Id: aws.String("333333333333"), Status: aws.String(organizations.AccountStatusActive)},
+			},
+			want: []string{"111111111111", "333333333333"},
+		},
+		{
+			name:    "error listing accounts",
+			err:     errors.New("access denied"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockOrgSvc := new(mocks.AwsOrganizations)
+			s := &orgService{svc: mockOrgSvc}
+			mockOrgSvc.On("ListAccountsPagesWithContext",
+				context.TODO(),
+				&organizations.ListAccountsInput{},
+				mock.AnythingOfType("func(*organizations.ListAccountsOutput, bool) bool"),
+			).Run(func(args mock.Arguments) {
+				fn := args.Get(2).(func(*organizations.ListAccountsOutput, bool) bool)
+				fn(&organizations.ListAccountsOutput{Accounts: tt.accounts}, true)
+			}).Return(tt.err).Once()
+
+			got, err := s.ListAccounts(context.TODO())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ListAccounts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListAccounts() got = %v, want %v", got, tt.want)
+			}
+			// assert mock
+			mockOrgSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_parseAccounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "accounts with comments and empty lines",
+			input: "# production\n111111111111\n\n  222222222222  # staging\n",
+			want:  []string{"111111111111", "222222222222"},
+		},
+		{
+			name:    "invalid account ID",
+			input:   "111111111111\n12345\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAccounts(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseAccounts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAccounts() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoleArn(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "replace account placeholder",
+			template: "arn:aws:iam::{account}:role/spotzero",
+			want:     "arn:aws:iam::111111111111:role/spotzero",
+		},
+		{
+			name:     "missing account placeholder",
+			template: "arn:aws:iam::123456789012:role/spotzero",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RoleArn(tt.template, "111111111111")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RoleArn() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("RoleArn() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -20,9 +20,11 @@ func MustAwsSession(roleARN, externalID, region string) (*session.Session, *aws.
 		config = config.WithRegion(region)
 	}
 
-	if (externalID != "") && (roleARN != "") {
+	if roleARN != "" {
 		creds := stscreds.NewCredentials(sess, roleARN, func(p *stscreds.AssumeRoleProvider) {
-			p.ExternalID = &externalID
+			if externalID != "" {
+				p.ExternalID = &externalID
+			}
 		})
 
 		config = config.WithCredentials(creds)
@@ -30,3 +32,8 @@ func MustAwsSession(roleARN, externalID, region string) (*session.Session, *aws.
 
 	return sess, config
 }
+
+// DefaultRegion returns the AWS Region from environment or shared config; empty if not configured
+func DefaultRegion() string {
+	return aws.StringValue(session.Must(session.NewSession()).Config.Region)
+}
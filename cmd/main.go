@@ -2,20 +2,28 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"runtime"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/doitintl/spotzero/aws/autoscaling"
+	"github.com/doitintl/spotzero/aws/ec2"
 	"github.com/doitintl/spotzero/aws/eventbridge"
+	"github.com/doitintl/spotzero/aws/organizations"
 	"github.com/doitintl/spotzero/aws/sts"
 	"github.com/urfave/cli/v2"
 
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	asg "github.com/aws/aws-sdk-go/service/autoscaling"
 )
 
 var (
@@ -35,28 +43,150 @@ var (
 	lambdaMode bool
 	// IAM Role to scan ASG groups
 	role sts.AssumeRoleInRegion
+	// AWS Regions to scan ASG groups in
+	regions []string
+	// discover AWS accounts with AWS Organizations
+	organizationMode bool
+	// file with AWS account IDs to scan
+	accountsFile string
+	// IAM Role ARN template for scanned AWS accounts
+	roleArnTemplate string
 	// IAM Role to put events into Event Bus
 	ebRole sts.AssumeRoleInRegion
 	// event bus ARN
 	eventBusArn string
 	// autoscaling config for similarity and on-demand base settings
 	asgConfig autoscaling.Config
+	// show update diff without updating ASG groups
+	dryRun bool
+	// recommendation output format
+	recommendOutput string
 )
 
 const (
-	autoscalingGroup = "autoscaling-group"
-	updateAsgInput   = "update-autoscaling-group-input"
+	autoscalingGroup        = "autoscaling-group"
+	skippedAutoscalingGroup = "skipped-autoscaling-group"
+	updateAsgInput          = "update-autoscaling-group-input"
+	// all enabled AWS Regions
+	allRegions = "all"
+	// recommendation output formats
+	outputInput = "input"
+	outputDiff  = "diff"
 )
 
-func parseTags(list []string) map[string]string {
-	tags := make(map[string]string, len(list))
-	for _, t := range list {
-		kv := strings.Split(t, "=")
-		if len(kv) == 2 {
-			tags[kv[0]] = kv[1]
+// groupEvent autoscaling group event tagged with AWS account, Region and applied config rule
+type groupEvent struct {
+	Account string `json:"account"`
+	Region  string `json:"region"`
+	Rule    string `json:"rule,omitempty"`
+	*asg.Group
+}
+
+// skippedGroupEvent skipped autoscaling group event tagged with AWS account, Region and skip reason
+type skippedGroupEvent struct {
+	Account string                 `json:"account"`
+	Region  string                 `json:"region"`
+	Reason  autoscaling.SkipReason `json:"reason"`
+	Message string                 `json:"message,omitempty"`
+	*asg.Group
+}
+
+// updateInputEvent autoscaling group update input event tagged with AWS account, Region and applied config rule,
+// with on-demand prices per capacity unit of the proposed instance types
+type updateInputEvent struct {
+	Account    string                  `json:"account"`
+	Region     string                  `json:"region"`
+	Rule       string                  `json:"rule,omitempty"`
+	UnitPrices []autoscaling.UnitPrice `json:"unitPrices,omitempty"`
+	*asg.UpdateAutoScalingGroupInput
+}
+
+// parseNameMatcher parses name patterns from the flag and the patterns file
+func parseNameMatcher(patterns []string, path string) (autoscaling.NameMatcher, error) {
+	if path != "" {
+		filePatterns, err := autoscaling.LoadNamePatterns(path)
+		if err != nil {
+			return autoscaling.NameMatcher{}, err
+		}
+		patterns = append(patterns, filePatterns...)
+	}
+	return autoscaling.ParseNameMatcher(patterns)
+}
+
+// parseGroupFilter parses tag selector and name patterns;
+// comma separated tag flag values are joined back into a single selector expression
+func parseGroupFilter(c *cli.Context) (autoscaling.GroupFilter, error) {
+	var filter autoscaling.GroupFilter
+	var err error
+	// every --tags selector is AND'ed as a unit, so `||` applies within its flag only
+	for _, expr := range c.StringSlice("tags") {
+		selector, err := autoscaling.ParseSelector(expr)
+		if err != nil {
+			return filter, err
+		}
+		filter.Tags = filter.Tags.And(selector)
+	}
+	filter.Names, err = parseNameMatcher(splitNamePatterns(c.StringSlice("name")), c.String("names-file"))
+	if err != nil {
+		return filter, err
+	}
+	filter.ExcludeNames, err = parseNameMatcher(splitNamePatterns(c.StringSlice("exclude-name")), c.String("exclude-names-file"))
+	if err != nil {
+		return filter, err
+	}
+	if path := c.String("config"); path != "" {
+		filter.Policy, err = autoscaling.LoadPolicy(path)
+	}
+	return filter, err
+}
+
+// ruleName returns the name of the config rule applied to the group or empty string
+func ruleName(policy *autoscaling.Policy, group *asg.Group) string {
+	if rule := policy.Match(group); rule != nil {
+		return rule.Name
+	}
+	return ""
+}
+
+// splitValues splits comma separated flag values (urfave/cli does not split slice flag values)
+func splitValues(values []string) []string {
+	var result []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
+
+// splitNamePatterns splits comma separated name patterns; regular expressions wrapped with slashes
+// are kept whole, since they can contain commas (`/^web-[0-9]{1,3}$/`)
+func splitNamePatterns(values []string) []string {
+	var result []string
+	for _, v := range values {
+		if p := strings.TrimSpace(v); len(p) > 1 && strings.HasPrefix(p, "/") && strings.HasSuffix(p, "/") {
+			result = append(result, p)
+			continue
 		}
+		result = append(result, splitValues([]string{v})...)
 	}
-	return tags
+	return result
+}
+
+// parseRefreshConfig reads instance refresh checkpoints and validates the update configuration
+func parseRefreshConfig(c *cli.Context) error {
+	asgConfig.CheckpointPercentages = nil
+	for _, v := range splitValues(c.StringSlice("checkpoint-percentages")) {
+		p, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid checkpoint percentage %q: %v", v, err)
+		}
+		asgConfig.CheckpointPercentages = append(asgConfig.CheckpointPercentages, p)
+	}
+	asgConfig.Lambda = lambdaMode
+	return asgConfig.Validate()
 }
 
 // handle Linux interruption signals
@@ -81,6 +211,140 @@ func handleSignals() context.Context {
 func init() {
 	// handle termination signal
 	mainCtx = handleSignals()
+	// put AWS Region log prefix after timestamp
+	log.SetFlags(log.LstdFlags | log.Lmsgprefix)
+}
+
+// get AWS account and Region from autoscaling group ARN
+func groupLocation(group *asg.Group) (account, region string) {
+	a, err := arn.Parse(aws.StringValue(group.AutoScalingGroupARN))
+	if err != nil {
+		return "", ""
+	}
+	return a.AccountID, a.Region
+}
+
+// accountRoles returns the role for every AWS account discovered with AWS Organizations or from the accounts file
+func accountRoles(role sts.AssumeRoleInRegion) ([]sts.AssumeRoleInRegion, error) {
+	var lister organizations.AccountLister
+	switch {
+	case accountsFile != "":
+		lister = organizations.NewFileAccountLister(accountsFile)
+	case organizationMode:
+		lister = organizations.NewAccountLister(role)
+	default:
+		return []sts.AssumeRoleInRegion{role}, nil
+	}
+	accounts, err := lister.ListAccounts(mainCtx)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("discovered %d AWS accounts", len(accounts))
+	roles := make([]sts.AssumeRoleInRegion, len(accounts))
+	for i, account := range accounts {
+		roleArn, err := organizations.RoleArn(roleArnTemplate, account)
+		if err != nil {
+			return nil, err
+		}
+		roles[i] = sts.AssumeRoleInRegion{Arn: roleArn, ExternalID: role.ExternalID, Region: role.Region}
+	}
+	return roles, nil
+}
+
+// flagRole warns that the --role-arn or --eb-role-arn role is assumed without an external ID
+func flagRole(role sts.AssumeRoleInRegion, flag string) sts.AssumeRoleInRegion {
+	if role.Arn != "" && role.ExternalID == "" {
+		log.Printf("warning: assuming --%s %v without external ID", flag, role.Arn)
+	}
+	return role
+}
+
+// get scan target name: AWS account (if role is assumed) and Region
+func targetName(role sts.AssumeRoleInRegion) string {
+	region := role.Region
+	if region == "" {
+		region = "default"
+	}
+	if a, err := arn.Parse(role.Arn); err == nil {
+		return a.AccountID + "/" + region
+	}
+	return region
+}
+
+// AWS Region to list enabled regions in, when no region is configured
+const bootstrapRegion = "us-east-1"
+
+var (
+	// replaced in tests
+	newRegionLister = ec2.NewRegionLister
+	defaultRegion   = sts.DefaultRegion
+)
+
+// regionRoles returns the role copy for every requested AWS Region
+func regionRoles(role sts.AssumeRoleInRegion, regions []string) ([]sts.AssumeRoleInRegion, error) {
+	for _, r := range regions {
+		if r == allRegions {
+			listerRole := role
+			if listerRole.Region == "" && defaultRegion() == "" {
+				listerRole.Region = bootstrapRegion
+			}
+			var err error
+			regions, err = newRegionLister(listerRole).ListRegions(mainCtx)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	// use default AWS Region (environment or shared config)
+	if len(regions) == 0 {
+		return []sts.AssumeRoleInRegion{role}, nil
+	}
+	roles := make([]sts.AssumeRoleInRegion, len(regions))
+	for i, r := range regions {
+		roles[i] = role
+		roles[i].Region = r
+	}
+	return roles, nil
+}
+
+// runInTargets runs handler for every requested AWS account and Region; skip on error (log only)
+// It returns an error listing all failed accounts and regions.
+func runInTargets(handler func(role sts.AssumeRoleInRegion) error) error {
+	accounts, err := accountRoles(role)
+	if err != nil {
+		return err
+	}
+	defer log.SetPrefix("")
+	var failed []string
+	total := 0
+	for _, account := range accounts {
+		log.SetPrefix(fmt.Sprintf("[%s] ", targetName(account)))
+		var roles []sts.AssumeRoleInRegion
+		roles, err = regionRoles(account, regions)
+		if err != nil {
+			log.Printf("failed to get regions: %v", err)
+			failed = append(failed, fmt.Sprintf("%s: %v", targetName(account), err))
+			total++
+			continue
+		}
+		for _, r := range roles {
+			total++
+			log.SetPrefix(fmt.Sprintf("[%s] ", targetName(r)))
+			err = handler(r)
+			if err != nil {
+				// report error to log and continue with other accounts and regions
+				log.Printf("failed to process: %v", err)
+				failed = append(failed, fmt.Sprintf("%s: %v", targetName(r), err))
+			} else {
+				log.Print("processed successfully")
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed in %d of %d targets: %s", len(failed), total, strings.Join(failed, "; "))
+	}
+	return nil
 }
 
 func getCallerIdentity(role sts.AssumeRoleInRegion) error {
@@ -93,9 +357,9 @@ func getCallerIdentity(role sts.AssumeRoleInRegion) error {
 	return nil
 }
 
-func listAutoscalingGroups(asgRole, ebRole sts.AssumeRoleInRegion, eventBusArn string, tags map[string]string) error {
+func listAutoscalingGroups(asgRole, ebRole sts.AssumeRoleInRegion, eventBusArn string, filter autoscaling.GroupFilter) error {
 	lister := autoscaling.NewLister(asgRole)
-	groups, err := lister.List(mainCtx, tags)
+	groups, skipped, err := lister.List(mainCtx, filter)
 	if err != nil {
 		return err
 	}
@@ -103,26 +367,47 @@ func listAutoscalingGroups(asgRole, ebRole sts.AssumeRoleInRegion, eventBusArn s
 		publisher := eventbridge.NewPublisher(ebRole, eventBusArn)
 		events := make([]interface{}, len(groups))
 		for i, v := range groups {
-			events[i] = v
+			account, region := groupLocation(v)
+			events[i] = groupEvent{account, region, ruleName(filter.Policy, v), v}
 		}
 		err := publisher.PublishEvents(mainCtx, events, autoscalingGroup)
 		if err != nil {
 			return err
 		}
+		events = make([]interface{}, len(skipped))
+		for i, v := range skipped {
+			account, region := groupLocation(v.Group)
+			events[i] = skippedGroupEvent{account, region, v.Reason, v.Message, v.Group}
+		}
+		err = publisher.PublishEvents(mainCtx, events, skippedAutoscalingGroup)
+		if err != nil {
+			return err
+		}
 	} else {
 		log.Print(groups)
+		for _, v := range groups {
+			if rule := ruleName(filter.Policy, v); rule != "" {
+				log.Printf("autoscaling group %v matches config rule %q", *v.AutoScalingGroupARN, rule)
+			}
+		}
+		for _, v := range skipped {
+			log.Printf("skipped autoscaling group %v: %v (%v)", *v.Group.AutoScalingGroupARN, v.Reason, v.Message)
+		}
 	}
 	return nil
 }
 
-func updateAutoscalingGroups(role sts.AssumeRoleInRegion, tags map[string]string) error {
+func updateAutoscalingGroups(role sts.AssumeRoleInRegion, filter autoscaling.GroupFilter) error {
 	lister := autoscaling.NewLister(role)
 	updater := autoscaling.NewUpdater(role, asgConfig)
-	// get list of ASG groups filtered by tags
-	groups, err := lister.List(mainCtx, tags)
+	// get list of ASG groups filtered by tags and names
+	groups, _, err := lister.List(mainCtx, filter)
 	if err != nil {
 		return err
 	}
+	if dryRun {
+		return diffAutoscalingGroups(updater, groups)
+	}
 	// update ASG groups one by one; skip on error (log only)
 	var updateError error // keep last update error
 	for _, group := range groups {
@@ -130,18 +415,55 @@ func updateAutoscalingGroups(role sts.AssumeRoleInRegion, tags map[string]string
 		err = updater.Update(mainCtx, group)
 		if err != nil {
 			// report error to log and try to update other groups
-			log.Printf("failed to update autoscaling group %v", *group.AutoScalingGroupARN)
+			log.Printf("failed to update autoscaling group %v (%v): %v", aws.StringValue(group.AutoScalingGroupName), aws.StringValue(group.AutoScalingGroupARN), err)
 			updateError = err
 		}
 	}
 	return updateError
 }
 
-func recommendAutoscalingGroups(role sts.AssumeRoleInRegion, tags map[string]string) error {
+// diffAutoscalingGroups logs diff between the current and proposed configuration of ASG groups
+func diffAutoscalingGroups(updater autoscaling.Updater, groups []*asg.Group) error {
+	var diffError error // keep last diff error
+	for _, group := range groups {
+		input, err := updater.CreateUpdateInput(mainCtx, group)
+		if err != nil {
+			log.Printf("failed to create update input for autoscaling group %v: %v", *group.AutoScalingGroupARN, err)
+			diffError = err
+			continue
+		}
+		log.Printf("dry run:\n%s", autoscaling.Diff(group, input))
+	}
+	return diffError
+}
+
+func rollbackAutoscalingGroups(role sts.AssumeRoleInRegion, filter autoscaling.GroupFilter, refresh bool) error {
 	lister := autoscaling.NewLister(role)
 	updater := autoscaling.NewUpdater(role, asgConfig)
-	// get list of ASG groups filtered by tags
-	groups, err := lister.List(mainCtx, tags)
+	// get list of ASG groups, updated by spotzero, filtered by tags and names
+	groups, err := lister.ListUpdated(mainCtx, filter)
+	if err != nil {
+		return err
+	}
+	// rollback ASG groups one by one; skip on error (log only)
+	var rollbackError error // keep last rollback error
+	for _, group := range groups {
+		log.Printf("rollback autoscaling group %v", *group.AutoScalingGroupARN)
+		err = updater.Rollback(mainCtx, group, refresh)
+		if err != nil {
+			// report error to log and try to rollback other groups
+			log.Printf("failed to rollback autoscaling group %v: %v", *group.AutoScalingGroupARN, err)
+			rollbackError = err
+		}
+	}
+	return rollbackError
+}
+
+func recommendAutoscalingGroups(role sts.AssumeRoleInRegion, filter autoscaling.GroupFilter) error {
+	lister := autoscaling.NewLister(role)
+	updater := autoscaling.NewUpdater(role, asgConfig)
+	// get list of ASG groups filtered by tags and names
+	groups, _, err := lister.List(mainCtx, filter)
 	if err != nil {
 		return err
 	}
@@ -156,16 +478,25 @@ func recommendAutoscalingGroups(role sts.AssumeRoleInRegion, tags map[string]str
 		input, err := updater.CreateUpdateInput(mainCtx, group)
 		if err != nil {
 			// report error to log and try to update other groups
-			log.Printf("failed to recommend optimization for autoscaling group %v", *group.AutoScalingGroupARN)
+			log.Printf("failed to recommend optimization for autoscaling group %v (%v): %v", aws.StringValue(group.AutoScalingGroupName), aws.StringValue(group.AutoScalingGroupARN), err)
 			recommendError = err
+			continue
+		}
+		if recommendOutput == outputDiff {
+			log.Print(autoscaling.Diff(group, input))
 		}
+		prices := autoscaling.UnitPrices(group, input)
 		if publisher != nil {
-			err := publisher.PublishEvents(mainCtx, []interface{}{input}, updateAsgInput)
+			account, region := groupLocation(group)
+			err := publisher.PublishEvents(mainCtx, []interface{}{updateInputEvent{account, region, ruleName(filter.Policy, group), prices, input}}, updateAsgInput)
 			if err != nil {
 				return err
 			}
-		} else {
+		} else if recommendOutput == outputInput {
 			log.Print(input)
+			if len(prices) > 0 {
+				log.Printf("on-demand price per unit hour: %v", prices)
+			}
 		}
 	}
 	return recommendError
@@ -187,46 +518,116 @@ func getCallerIdentityCmd(c *cli.Context) error {
 // =========== List ASG groups Handlers ===========
 
 func listAutoscalingGroupsCmd(c *cli.Context) error {
-	tags := parseTags(c.StringSlice("tags"))
-	log.Printf("get autoscaling groups filtered by %v", tags)
+	filter, err := parseGroupFilter(c)
+	if err != nil {
+		return err
+	}
+	log.Printf("get autoscaling groups filtered by %v", filter)
+	ebRole = flagRole(ebRole, "eb-role-arn")
 	// handle lambda or cli
 	if lambdaMode {
 		lambda.StartWithContext(mainCtx, func(ctx context.Context) error {
-			return listAutoscalingGroups(role, ebRole, eventBusArn, tags)
+			return runInTargets(func(role sts.AssumeRoleInRegion) error {
+				return listAutoscalingGroups(role, ebRole, eventBusArn, filter)
+			})
 		})
 		return nil
 	}
-	return listAutoscalingGroups(role, ebRole, eventBusArn, tags)
+	return runInTargets(func(role sts.AssumeRoleInRegion) error {
+		return listAutoscalingGroups(role, ebRole, eventBusArn, filter)
+	})
 }
 
 // =========== Update ASG groups Handlers ===========
 
 func updateAutoscalingGroupsCmd(c *cli.Context) error {
-	tags := parseTags(c.StringSlice("tags"))
-	log.Printf("update autoscaling groups filtered by %v", tags)
+	filter, err := parseGroupFilter(c)
+	if err != nil {
+		return err
+	}
+	if err = parseRefreshConfig(c); err != nil {
+		return err
+	}
+	asgConfig.Policy = filter.Policy
+	// groups with launch configuration are eligible for update, when migration is enabled
+	filter.LaunchConfigurations = asgConfig.MigrateLaunchConfigurations
+	// groups updated by spotzero are eligible for update after the re-optimization interval
+	filter.ReoptimizeAfter = c.Duration("reoptimize-after")
+	if filter.ReoptimizeAfter < 0 {
+		return fmt.Errorf("invalid re-optimization interval %v: expected non-negative duration", filter.ReoptimizeAfter)
+	}
+	if dryRun {
+		log.Print("dry run: autoscaling groups are not updated")
+	}
+	log.Printf("update autoscaling groups filtered by %v", filter)
 	// handle lambda or cli
 	if lambdaMode {
 		lambda.StartWithContext(mainCtx, func(ctx context.Context) error {
-			return updateAutoscalingGroups(role, tags)
+			return runInTargets(func(role sts.AssumeRoleInRegion) error {
+				return updateAutoscalingGroups(role, filter)
+			})
 		})
 		return nil
 	}
-	return updateAutoscalingGroups(role, tags)
+	return runInTargets(func(role sts.AssumeRoleInRegion) error {
+		return updateAutoscalingGroups(role, filter)
+	})
 }
 
 // =========== Update ASG groups Handlers ===========
 
 func recommendAutoscalingGroupsCmd(c *cli.Context) error {
-	tags := parseTags(c.StringSlice("tags"))
-	log.Printf("recommend optimization for autoscaling groups filtered by %v", tags)
+	filter, err := parseGroupFilter(c)
+	if err != nil {
+		return err
+	}
+	if recommendOutput != outputInput && recommendOutput != outputDiff {
+		return fmt.Errorf("invalid output format %q: expected %q or %q", recommendOutput, outputInput, outputDiff)
+	}
+	ebRole = flagRole(ebRole, "eb-role-arn")
+	if err = asgConfig.Validate(); err != nil {
+		return err
+	}
+	asgConfig.Policy = filter.Policy
+	log.Printf("recommend optimization for autoscaling groups filtered by %v", filter)
+	// handle lambda or cli
+	if lambdaMode {
+		lambda.StartWithContext(mainCtx, func(ctx context.Context) error {
+			return runInTargets(func(role sts.AssumeRoleInRegion) error {
+				return recommendAutoscalingGroups(role, filter)
+			})
+		})
+		return nil
+	}
+	return runInTargets(func(role sts.AssumeRoleInRegion) error {
+		return recommendAutoscalingGroups(role, filter)
+	})
+}
+
+// =========== Rollback ASG groups Handlers ===========
+
+func rollbackAutoscalingGroupsCmd(c *cli.Context) error {
+	filter, err := parseGroupFilter(c)
+	if err != nil {
+		return err
+	}
+	if err = parseRefreshConfig(c); err != nil {
+		return err
+	}
+	refresh := c.Bool("refresh-instances")
+	log.Printf("rollback autoscaling groups filtered by %v", filter)
 	// handle lambda or cli
 	if lambdaMode {
 		lambda.StartWithContext(mainCtx, func(ctx context.Context) error {
-			return recommendAutoscalingGroups(role, tags)
+			return runInTargets(func(role sts.AssumeRoleInRegion) error {
+				return rollbackAutoscalingGroups(role, filter, refresh)
+			})
 		})
 		return nil
 	}
-	return recommendAutoscalingGroups(role, tags)
+	return runInTargets(func(role sts.AssumeRoleInRegion) error {
+		return rollbackAutoscalingGroups(role, filter, refresh)
+	})
 }
 
 // =========== MAIN ===========
@@ -256,11 +657,27 @@ func main() {
 			Destination: &ebRole.Region,
 		},
 	}
-	// tag flags
-	tagFlags := []cli.Flag{
+	// tag and name filter flags
+	filterFlags := []cli.Flag{
 		&cli.StringSliceFlag{
 			Name:  "tags",
-			Usage: "tags to filter by (syntax: key=value)",
+			Usage: "tag selector to filter by (syntax: key=value, key!=value, key, !key, key in (v1,v2), key notin (v1,v2); values support * wildcard; ',' for AND, '||' for OR)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "name",
+			Usage: "autoscaling group names, ARNs, globs (web-*) or regular expressions (/^web-[0-9]+$/) to filter by",
+		},
+		&cli.StringFlag{
+			Name:  "names-file",
+			Usage: "file with autoscaling group names, ARNs, globs or regular expressions (one per line) to filter by",
+		},
+		&cli.StringSliceFlag{
+			Name:  "exclude-name",
+			Usage: "autoscaling group names, ARNs, globs or regular expressions to exclude",
+		},
+		&cli.StringFlag{
+			Name:  "exclude-names-file",
+			Usage: "file with autoscaling group names, ARNs, globs or regular expressions (one per line) to exclude",
 		},
 	}
 	// shared similarity tune up flags
@@ -291,6 +708,51 @@ func main() {
 			Value:       2,
 			Destination: &asgConfig.SimilarityConfig.MultiplyFactorLower,
 		},
+		&cli.Float64Flag{
+			Name:        "memory-per-vcpu-tolerance",
+			Usage:       "maximum relative difference (0.25 for 25%) between memory per VCPU of similar and original instance types; 0 does not compare memory per VCPU",
+			Destination: &asgConfig.SimilarityConfig.MemoryPerVCPUTolerance,
+		},
+		&cli.Float64Flag{
+			Name:        "min-memory",
+			Usage:       "minimum memory (GiB) of similar instance types",
+			Destination: &asgConfig.SimilarityConfig.MinMemory,
+		},
+		&cli.Float64Flag{
+			Name:        "max-memory",
+			Usage:       "maximum memory (GiB) of similar instance types",
+			Destination: &asgConfig.SimilarityConfig.MaxMemory,
+		},
+		&cli.BoolFlag{
+			Name:        "ignore-instance-store",
+			Usage:       "allow similar instance types without instance store volumes, when the original instance type has them",
+			Destination: &asgConfig.SimilarityConfig.IgnoreInstanceStore,
+		},
+		&cli.Float64Flag{
+			Name:        "min-instance-store",
+			Usage:       "minimum total instance store size (GB) of similar instance types",
+			Destination: &asgConfig.SimilarityConfig.MinInstanceStore,
+		},
+		&cli.BoolFlag{
+			Name:        "ignore-network-performance",
+			Usage:       "allow similar instance types with lower network performance than the original instance type (sustained from 25 Gbps, peak below)",
+			Destination: &asgConfig.SimilarityConfig.IgnoreNetworkPerformance,
+		},
+		&cli.Float64Flag{
+			Name:        "min-network-performance",
+			Usage:       "minimum sustained network performance (Gbps) of similar instance types; burstable (Up to) network performance does not qualify",
+			Destination: &asgConfig.SimilarityConfig.MinNetworkPerformance,
+		},
+		&cli.BoolFlag{
+			Name:        "ignore-ebs-bandwidth",
+			Usage:       "allow similar instance types with lower EBS bandwidth than the original instance type with 10000 Mbps or more",
+			Destination: &asgConfig.SimilarityConfig.IgnoreEBSBandwidth,
+		},
+		&cli.Float64Flag{
+			Name:        "min-ebs-bandwidth",
+			Usage:       "minimum sustained EBS bandwidth (Mbps) of similar instance types",
+			Destination: &asgConfig.SimilarityConfig.MinEBSBandwidth,
+		},
 		&cli.Int64Flag{
 			Name:        "ondemand-base-capacity",
 			Aliases:     []string{"obc"},
@@ -305,6 +767,116 @@ func main() {
 			Value:       0,
 			Destination: &asgConfig.OnDemandPercentageAboveBaseCapacity,
 		},
+		&cli.StringFlag{
+			Name:        "spot-allocation-strategy",
+			Usage:       "spot allocation strategy: capacity-optimized, capacity-optimized-prioritized, price-capacity-optimized or lowest-price",
+			Value:       autoscaling.SpotCapacityOptimized,
+			Destination: &asgConfig.SpotAllocationStrategy,
+		},
+		&cli.Int64Flag{
+			Name:        "spot-instance-pools",
+			Usage:       "number of spot pools (1-20) to allocate spot instances from; only with lowest-price spot allocation strategy",
+			Destination: &asgConfig.SpotInstancePools,
+		},
+		&cli.StringFlag{
+			Name:        "ondemand-allocation-strategy",
+			Usage:       "on-demand allocation strategy: prioritized (instance types similarity order) or lowest-price",
+			Destination: &asgConfig.OnDemandAllocationStrategy,
+		},
+		&cli.BoolFlag{
+			Name:        "instance-requirements",
+			Usage:       "use attributes of similar instance types (VCPU and memory ranges, CPU manufacturers, generations) instead of a list of instance types",
+			Destination: &asgConfig.InstanceRequirements,
+		},
+		&cli.StringFlag{
+			Name:        "weighting",
+			Usage:       "instance types weighting: vcpu (default), memory (GiB), normalized (units of the original instance type) or none",
+			Destination: &asgConfig.Weighting,
+		},
+		&cli.StringFlag{
+			Name:        "arm-image-id",
+			Usage:       "arm64 AMI ID: add similar Graviton instance types with a copy of the autoscaling group launch template, that uses this AMI",
+			Destination: &asgConfig.ArmImageID,
+		},
+		&cli.StringFlag{
+			Name:        "arm-launch-template",
+			Usage:       "arm64 launch template (id-or-name[:version]): add similar Graviton instance types with this launch template",
+			Destination: &asgConfig.ArmLaunchTemplate,
+		},
+		&cli.StringFlag{
+			Name:        "spot-max-price",
+			Usage:       "maximum price per VCPU hour for spot instances: absolute price (0.05) or percentage of the original instance type on-demand price (80%)",
+			Destination: &asgConfig.SpotMaxPrice,
+		},
+		&cli.BoolFlag{
+			Name:        "capacity-rebalance",
+			Usage:       "enable Capacity Rebalancing: replace spot instances at elevated risk of interruption; --capacity-rebalance=false keeps the autoscaling group setting",
+			Value:       true,
+			Destination: &asgConfig.CapacityRebalance,
+		},
+		&cli.Int64Flag{
+			Name:        "max-instance-lifetime",
+			Usage:       "maximum number of seconds (86400-31536000) an instance can be in service; 0 keeps the autoscaling group setting",
+			Destination: &asgConfig.MaxInstanceLifetime,
+		},
+		&cli.BoolFlag{
+			Name:        "require-all-zones",
+			Usage:       "skip similar instance types, that are not offered in every Availability Zone of the autoscaling group",
+			Destination: &asgConfig.RequireAllZones,
+		},
+		&cli.StringFlag{
+			Name:        "price-ranking",
+			Usage:       "rank similar instance types by price per capacity unit: ondemand (region on-demand price) or spot (current spot price in the autoscaling group Availability Zones)",
+			Destination: &asgConfig.PriceRanking,
+		},
+		&cli.Float64Flag{
+			Name:        "max-price-increase",
+			Usage:       "skip similar instance types priced more than this percentage per capacity unit above the original instance type; 0 does not compare prices",
+			Destination: &asgConfig.MaxPriceIncrease,
+		},
+	}
+	configFlag := &cli.StringFlag{
+		Name:  "config",
+		Usage: "YAML or JSON config file with rules, that select autoscaling groups and override update settings (first matching rule wins)",
+	}
+	refreshFlags := []cli.Flag{
+		&cli.Int64Flag{
+			Name:        "min-healthy-percentage",
+			Usage:       "percentage of capacity that must remain healthy during an instance refresh",
+			Value:       autoscaling.DefaultMinHealthyPercentage,
+			Destination: &asgConfig.MinHealthyPercentage,
+		},
+		&cli.Int64Flag{
+			Name:        "instance-warmup",
+			Usage:       "number of seconds until a newly launched instance is ready to use",
+			Value:       autoscaling.DefaultInstanceWarmup,
+			Destination: &asgConfig.InstanceWarmup,
+		},
+		&cli.StringSliceFlag{
+			Name:  "checkpoint-percentages",
+			Usage: "ascending percentages of replaced instances to pause instance refresh at (comma separated list)",
+		},
+		&cli.Int64Flag{
+			Name:        "checkpoint-delay",
+			Usage:       "number of seconds to wait after a checkpoint",
+			Destination: &asgConfig.CheckpointDelay,
+		},
+		&cli.BoolFlag{
+			Name:        "skip-matching",
+			Usage:       "skip replacing instances that already match the desired configuration",
+			Destination: &asgConfig.SkipMatching,
+		},
+		&cli.BoolFlag{
+			Name:        "wait",
+			Usage:       "wait for the instance refresh to complete; fail if the instance refresh fails or is cancelled",
+			Destination: &asgConfig.WaitForRefresh,
+		},
+		&cli.DurationFlag{
+			Name:        "wait-timeout",
+			Usage:       "maximum time to wait for the instance refresh; the instance refresh is cancelled after the timeout",
+			Value:       time.Hour,
+			Destination: &asgConfig.RefreshTimeout,
+		},
 	}
 	// main app
 	app := &cli.App{
@@ -324,30 +896,97 @@ func main() {
 				Usage:       "external ID to assume role with",
 				Destination: &role.ExternalID,
 			},
+			&cli.StringSliceFlag{
+				Name:  "region",
+				Usage: "the AWS Regions to send the request to (comma separated list or \"all\" for all enabled regions)",
+			},
+			&cli.BoolFlag{
+				Name:        "organization",
+				Usage:       "scan all active AWS Organizations member accounts",
+				Destination: &organizationMode,
+			},
+			&cli.StringFlag{
+				Name:        "accounts-file",
+				Usage:       "scan AWS accounts listed in the file (one account ID per line)",
+				Destination: &accountsFile,
+			},
 			&cli.StringFlag{
-				Name:        "region",
-				Usage:       "the AWS Region to send the request to",
-				Destination: &role.Region,
+				Name:        "role-arn-template",
+				Usage:       "role ARN to assume in every scanned AWS account",
+				Value:       "arn:aws:iam::" + organizations.AccountPlaceholder + ":role/spotzero",
+				Destination: &roleArnTemplate,
 			},
 		},
+		Before: func(c *cli.Context) error {
+			if organizationMode && accountsFile != "" {
+				return errors.New("--organization and --accounts-file flags are mutually exclusive")
+			}
+			role = flagRole(role, "role-arn")
+			regions = splitValues(c.StringSlice("region"))
+			// single region commands use the first region
+			if len(regions) > 0 && regions[0] != allRegions {
+				role.Region = regions[0]
+			}
+			return nil
+		},
 		Commands: []*cli.Command{
 			{
 				Name:   "list",
-				Usage:  "list EC2 autoscaling groups, filtered by tags",
+				Usage:  "list EC2 autoscaling groups, filtered by tags and names",
 				Action: listAutoscalingGroupsCmd,
-				Flags:  append(sharedFlags, tagFlags...),
+				Flags:  append(append([]cli.Flag{configFlag}, sharedFlags...), filterFlags...),
 			},
 			{
 				Name:   "update",
 				Usage:  "update EC2 autoscaling groups to maximize Spot usage",
 				Action: updateAutoscalingGroupsCmd,
-				Flags:  append(similarFlags, tagFlags...),
+				Flags: append(append([]cli.Flag{
+					configFlag,
+					&cli.BoolFlag{
+						Name:        "migrate-launch-configurations",
+						Usage:       "create launch templates for autoscaling groups with launch configuration and update them",
+						Destination: &asgConfig.MigrateLaunchConfigurations,
+					},
+					&cli.BoolFlag{
+						Name:        "no-refresh",
+						Usage:       "do not start instance refresh after the update; let natural churn replace instances",
+						Destination: &asgConfig.NoRefresh,
+					},
+					&cli.DurationFlag{
+						Name:  "reoptimize-after",
+						Usage: "re-optimize autoscaling groups updated by spotzero longer than this duration ago (for example, 168h); updated only if the policy changes",
+					},
+					&cli.BoolFlag{
+						Name:        "dry-run",
+						Usage:       "show diff between the current and proposed configuration without updating autoscaling groups",
+						Destination: &dryRun,
+					},
+				}, similarFlags...), append(refreshFlags, filterFlags...)...),
 			},
 			{
 				Name:   "recommend",
 				Usage:  "recommend optimization for EC2 autoscaling groups to maximize Spot usage",
 				Action: recommendAutoscalingGroupsCmd,
-				Flags:  append(append(sharedFlags, similarFlags...), tagFlags...),
+				Flags: append(append(append([]cli.Flag{
+					configFlag,
+					&cli.StringFlag{
+						Name:        "output",
+						Usage:       "recommendation output format: input (UpdateAutoScalingGroup request) or diff (current vs proposed configuration)",
+						Value:       outputInput,
+						Destination: &recommendOutput,
+					},
+				}, sharedFlags...), similarFlags...), filterFlags...),
+			},
+			{
+				Name:   "rollback",
+				Usage:  "restore EC2 autoscaling groups updated by spotzero to the original configuration",
+				Action: rollbackAutoscalingGroupsCmd,
+				Flags: append([]cli.Flag{
+					&cli.BoolFlag{
+						Name:  "refresh-instances",
+						Usage: "start instance refresh after restoring the original configuration",
+					},
+				}, append(refreshFlags, filterFlags...)...),
 			},
 			{
 				Name:   "get-caller-identity",
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	asg "github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/doitintl/spotzero/aws/autoscaling"
+	"github.com/doitintl/spotzero/aws/ec2"
+	"github.com/doitintl/spotzero/aws/sts"
+	ec2mocks "github.com/doitintl/spotzero/mocks/ec2"
+)
+
+func Test_splitValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   []string
+	}{
+		{
+			name: "no values",
+		},
+		{
+			name:   "repeated flag",
+			values: []string{"us-east-1", "eu-west-1"},
+			want:   []string{"us-east-1", "eu-west-1"},
+		},
+		{
+			name:   "comma separated list",
+			values: []string{"us-east-1,eu-west-1", "ap-south-1"},
+			want:   []string{"us-east-1", "eu-west-1", "ap-south-1"},
+		},
+		{
+			name:   "trim spaces and skip empty values",
+			values: []string{" us-east-1 , ,eu-west-1,", ""},
+			want:   []string{"us-east-1", "eu-west-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitValues(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_splitNamePatterns(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   []string
+	}{
+		{
+			name:   "comma separated names",
+			values: []string{"web-1, web-*", "api-1"},
+			want:   []string{"web-1", "web-*", "api-1"},
+		},
+		{
+			name:   "regular expression with commas",
+			values: []string{" /^web-[0-9]{1,3}$/ ", "api-1,api-2"},
+			want:   []string{"/^web-[0-9]{1,3}$/", "api-1", "api-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitNamePatterns(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitNamePatterns() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_regionRoles(t *testing.T) {
+	tests := []struct {
+		name          string
+		role          sts.AssumeRoleInRegion
+		regions       []string
+		defaultRegion string
+		listed        []string
+		listErr       error
+		listerRegion  string
+		want          []sts.AssumeRoleInRegion
+		wantErr       bool
+	}{
+		{
+			name: "default region",
+			role: sts.AssumeRoleInRegion{Arn: "arn:aws:iam::123456789012:role/test"},
+			want: []sts.AssumeRoleInRegion{{Arn: "arn:aws:iam::123456789012:role/test"}},
+		},
+		{
+			name:    "requested regions",
+			role:    sts.AssumeRoleInRegion{Arn: "arn:aws:iam::123456789012:role/test", Region: "us-east-1"},
+			regions: []string{"us-east-1", "eu-west-1"},
+			want: []sts.AssumeRoleInRegion{
+				{Arn: "arn:aws:iam::123456789012:role/test", Region: "us-east-1"},
+				{Arn: "arn:aws:iam::123456789012:role/test", Region: "eu-west-1"},
+			},
+		},
+		{
+			name:         "all regions in the first region",
+			role:         sts.AssumeRoleInRegion{Region: "eu-west-1"},
+			regions:      []string{"eu-west-1", "all"},
+			listed:       []string{"eu-west-1", "us-east-1"},
+			listerRegion: "eu-west-1",
+			want:         []sts.AssumeRoleInRegion{{Region: "eu-west-1"}, {Region: "us-east-1"}},
+		},
+		{
+			name:          "all regions in the default region",
+			regions:       []string{"all"},
+			defaultRegion: "eu-central-1",
+			listed:        []string{"eu-central-1", "us-east-1"},
+			want:          []sts.AssumeRoleInRegion{{Region: "eu-central-1"}, {Region: "us-east-1"}},
+		},
+		{
+			name:         "all regions without default region",
+			regions:      []string{"all"},
+			listed:       []string{"eu-central-1", "us-east-1"},
+			listerRegion: bootstrapRegion,
+			want:         []sts.AssumeRoleInRegion{{Region: "eu-central-1"}, {Region: "us-east-1"}},
+		},
+		{
+			name:         "fail to list regions",
+			regions:      []string{"all"},
+			listErr:      errors.New("access denied"),
+			listerRegion: bootstrapRegion,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mainCtx = context.TODO()
+			mockLister := new(ec2mocks.RegionLister)
+			if tt.listed != nil || tt.listErr != nil {
+				mockLister.On("ListRegions", mainCtx).Return(tt.listed, tt.listErr).Once()
+			}
+			var listerRole *sts.AssumeRoleInRegion
+			newRegionLister = func(role sts.AssumeRoleInRegion) ec2.RegionLister {
+				listerRole = &role
+				return mockLister
+			}
+			defaultRegion = func() string { return tt.defaultRegion }
+			defer func() {
+				newRegionLister = ec2.NewRegionLister
+				defaultRegion = sts.DefaultRegion
+			}()
+			got, err := regionRoles(tt.role, tt.regions)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("regionRoles() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("regionRoles() = %v, want %v", got, tt.want)
+			}
+			if listerRole != nil && listerRole.Region != tt.listerRegion {
+				t.Errorf("regionRoles() listed regions in %q, want %q", listerRole.Region, tt.listerRegion)
+			}
+			mockLister.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_flagRole(t *testing.T) {
+	tests := []struct {
+		name string
+		role sts.AssumeRoleInRegion
+		want sts.AssumeRoleInRegion
+	}{
+		{
+			name: "default credentials",
+			role: sts.AssumeRoleInRegion{Region: "us-east-1"},
+			want: sts.AssumeRoleInRegion{Region: "us-east-1"},
+		},
+		{
+			name: "role with external ID",
+			role: sts.AssumeRoleInRegion{Arn: "arn:aws:iam::123456789012:role/test", ExternalID: "test", Region: "us-east-1"},
+			want: sts.AssumeRoleInRegion{Arn: "arn:aws:iam::123456789012:role/test", ExternalID: "test", Region: "us-east-1"},
+		},
+		{
+			name: "role without external ID is assumed",
+			role: sts.AssumeRoleInRegion{Arn: "arn:aws:iam::123456789012:role/test", Region: "us-east-1"},
+			want: sts.AssumeRoleInRegion{Arn: "arn:aws:iam::123456789012:role/test", Region: "us-east-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := flagRole(tt.role, "role-arn"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("flagRole() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_updateInputEvent(t *testing.T) {
+	event := updateInputEvent{
+		Account:                     "123456789012",
+		Region:                      "us-east-1",
+		UnitPrices:                  []autoscaling.UnitPrice{{InstanceType: "m5.large", Price: 0.048}},
+		UpdateAutoScalingGroupInput: &asg.UpdateAutoScalingGroupInput{AutoScalingGroupName: aws.String("test-asg")},
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var got map[string]interface{}
+	if err = json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	wantPrices := []interface{}{map[string]interface{}{"instanceType": "m5.large", "onDemandPrice": 0.048}}
+	if !reflect.DeepEqual(got["unitPrices"], wantPrices) || got["AutoScalingGroupName"] != "test-asg" || got["region"] != "us-east-1" {
+		t.Errorf("json.Marshal() = %s, want unit prices %v with the update input", data, wantPrices)
+	}
+}
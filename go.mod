@@ -4,7 +4,7 @@ go 1.15
 
 require (
 	github.com/aws/aws-lambda-go v1.21.0
-	github.com/aws/aws-sdk-go v1.36.14
+	github.com/aws/aws-sdk-go v1.44.0
 	github.com/cristim/ec2-instances-info v0.0.0-20210201160642-80270dab05f8
 	github.com/golangci/golangci-lint v1.36.0 // indirect
 	github.com/kr/text v0.2.0 // indirect
@@ -14,11 +14,9 @@ require (
 	github.com/stretchr/objx v0.3.0 // indirect
 	github.com/stretchr/testify v1.7.0
 	github.com/urfave/cli/v2 v2.3.0
-	golang.org/x/net v0.0.0-20201202161906-c7110b5ffcbb // indirect
-	golang.org/x/text v0.3.5 // indirect
 	gopkg.in/check.v1 v1.0.0-20200227125254-8fa46927fb4f // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
 
 // replace github.com/cristim/ec2-instances-info => github.com/alexei-led/ec2-instances-info v0.0.0-20210201135146-4883eec56363
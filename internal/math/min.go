@@ -7,3 +7,11 @@ func MinInt(a, b int) int {
 	}
 	return b
 }
+
+// MaxInt returns the maximum of two ints
+func MaxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -17,6 +17,36 @@ type AwsAsgUpdater struct {
 	mock.Mock
 }
 
+// CancelInstanceRefreshWithContext provides a mock function with given fields: _a0, _a1, _a2
+func (_m *AwsAsgUpdater) CancelInstanceRefreshWithContext(_a0 context.Context, _a1 *autoscaling.CancelInstanceRefreshInput, _a2 ...request.Option) (*autoscaling.CancelInstanceRefreshOutput, error) {
+	_va := make([]interface{}, len(_a2))
+	for _i := range _a2 {
+		_va[_i] = _a2[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _a0, _a1)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *autoscaling.CancelInstanceRefreshOutput
+	if rf, ok := ret.Get(0).(func(context.Context, *autoscaling.CancelInstanceRefreshInput, ...request.Option) *autoscaling.CancelInstanceRefreshOutput); ok {
+		r0 = rf(_a0, _a1, _a2...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*autoscaling.CancelInstanceRefreshOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *autoscaling.CancelInstanceRefreshInput, ...request.Option) error); ok {
+		r1 = rf(_a0, _a1, _a2...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateOrUpdateTagsWithContext provides a mock function with given fields: _a0, _a1, _a2
 func (_m *AwsAsgUpdater) CreateOrUpdateTagsWithContext(_a0 context.Context, _a1 *autoscaling.CreateOrUpdateTagsInput, _a2 ...request.Option) (*autoscaling.CreateOrUpdateTagsOutput, error) {
 	_va := make([]interface{}, len(_a2))
@@ -47,6 +77,186 @@ func (_m *AwsAsgUpdater) CreateOrUpdateTagsWithContext(_a0 context.Context, _a1
 	return r0, r1
 }
 
+// DeleteTagsWithContext provides a mock function with given fields: _a0, _a1, _a2
+func (_m *AwsAsgUpdater) DeleteTagsWithContext(_a0 context.Context, _a1 *autoscaling.DeleteTagsInput, _a2 ...request.Option) (*autoscaling.DeleteTagsOutput, error) {
+	_va := make([]interface{}, len(_a2))
+	for _i := range _a2 {
+		_va[_i] = _a2[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _a0, _a1)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *autoscaling.DeleteTagsOutput
+	if rf, ok := ret.Get(0).(func(context.Context, *autoscaling.DeleteTagsInput, ...request.Option) *autoscaling.DeleteTagsOutput); ok {
+		r0 = rf(_a0, _a1, _a2...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*autoscaling.DeleteTagsOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *autoscaling.DeleteTagsInput, ...request.Option) error); ok {
+		r1 = rf(_a0, _a1, _a2...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DescribeInstanceRefreshesWithContext provides a mock function with given fields: _a0, _a1, _a2
+func (_m *AwsAsgUpdater) DescribeInstanceRefreshesWithContext(_a0 context.Context, _a1 *autoscaling.DescribeInstanceRefreshesInput, _a2 ...request.Option) (*autoscaling.DescribeInstanceRefreshesOutput, error) {
+	_va := make([]interface{}, len(_a2))
+	for _i := range _a2 {
+		_va[_i] = _a2[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _a0, _a1)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *autoscaling.DescribeInstanceRefreshesOutput
+	if rf, ok := ret.Get(0).(func(context.Context, *autoscaling.DescribeInstanceRefreshesInput, ...request.Option) *autoscaling.DescribeInstanceRefreshesOutput); ok {
+		r0 = rf(_a0, _a1, _a2...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*autoscaling.DescribeInstanceRefreshesOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *autoscaling.DescribeInstanceRefreshesInput, ...request.Option) error); ok {
+		r1 = rf(_a0, _a1, _a2...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DescribeLaunchConfigurationsWithContext provides a mock function with given fields: _a0, _a1, _a2
+func (_m *AwsAsgUpdater) DescribeLaunchConfigurationsWithContext(_a0 context.Context, _a1 *autoscaling.DescribeLaunchConfigurationsInput, _a2 ...request.Option) (*autoscaling.DescribeLaunchConfigurationsOutput, error) {
+	_va := make([]interface{}, len(_a2))
+	for _i := range _a2 {
+		_va[_i] = _a2[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _a0, _a1)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *autoscaling.DescribeLaunchConfigurationsOutput
+	if rf, ok := ret.Get(0).(func(context.Context, *autoscaling.DescribeLaunchConfigurationsInput, ...request.Option) *autoscaling.DescribeLaunchConfigurationsOutput); ok {
+		r0 = rf(_a0, _a1, _a2...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*autoscaling.DescribeLaunchConfigurationsOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *autoscaling.DescribeLaunchConfigurationsInput, ...request.Option) error); ok {
+		r1 = rf(_a0, _a1, _a2...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DescribePoliciesWithContext provides a mock function with given fields: _a0, _a1, _a2
+func (_m *AwsAsgUpdater) DescribePoliciesWithContext(_a0 context.Context, _a1 *autoscaling.DescribePoliciesInput, _a2 ...request.Option) (*autoscaling.DescribePoliciesOutput, error) {
+	_va := make([]interface{}, len(_a2))
+	for _i := range _a2 {
+		_va[_i] = _a2[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _a0, _a1)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *autoscaling.DescribePoliciesOutput
+	if rf, ok := ret.Get(0).(func(context.Context, *autoscaling.DescribePoliciesInput, ...request.Option) *autoscaling.DescribePoliciesOutput); ok {
+		r0 = rf(_a0, _a1, _a2...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*autoscaling.DescribePoliciesOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *autoscaling.DescribePoliciesInput, ...request.Option) error); ok {
+		r1 = rf(_a0, _a1, _a2...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DescribeScheduledActionsWithContext provides a mock function with given fields: _a0, _a1, _a2
+func (_m *AwsAsgUpdater) DescribeScheduledActionsWithContext(_a0 context.Context, _a1 *autoscaling.DescribeScheduledActionsInput, _a2 ...request.Option) (*autoscaling.DescribeScheduledActionsOutput, error) {
+	_va := make([]interface{}, len(_a2))
+	for _i := range _a2 {
+		_va[_i] = _a2[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _a0, _a1)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *autoscaling.DescribeScheduledActionsOutput
+	if rf, ok := ret.Get(0).(func(context.Context, *autoscaling.DescribeScheduledActionsInput, ...request.Option) *autoscaling.DescribeScheduledActionsOutput); ok {
+		r0 = rf(_a0, _a1, _a2...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*autoscaling.DescribeScheduledActionsOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *autoscaling.DescribeScheduledActionsInput, ...request.Option) error); ok {
+		r1 = rf(_a0, _a1, _a2...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StartInstanceRefreshWithContext provides a mock function with given fields: _a0, _a1, _a2
+func (_m *AwsAsgUpdater) StartInstanceRefreshWithContext(_a0 context.Context, _a1 *autoscaling.StartInstanceRefreshInput, _a2 ...request.Option) (*autoscaling.StartInstanceRefreshOutput, error) {
+	_va := make([]interface{}, len(_a2))
+	for _i := range _a2 {
+		_va[_i] = _a2[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _a0, _a1)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *autoscaling.StartInstanceRefreshOutput
+	if rf, ok := ret.Get(0).(func(context.Context, *autoscaling.StartInstanceRefreshInput, ...request.Option) *autoscaling.StartInstanceRefreshOutput); ok {
+		r0 = rf(_a0, _a1, _a2...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*autoscaling.StartInstanceRefreshOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *autoscaling.StartInstanceRefreshInput, ...request.Option) error); ok {
+		r1 = rf(_a0, _a1, _a2...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateAutoScalingGroupWithContext provides a mock function with given fields: _a0, _a1, _a2
 func (_m *AwsAsgUpdater) UpdateAutoScalingGroupWithContext(_a0 context.Context, _a1 *autoscaling.UpdateAutoScalingGroupInput, _a2 ...request.Option) (*autoscaling.UpdateAutoScalingGroupOutput, error) {
 	_va := make([]interface{}, len(_a2))